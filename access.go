@@ -0,0 +1,118 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bartdeboer/cobrahooks"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// TraceAccess records the config keys that are read, with Get and the other getters, Unmarshal
+// and bound Structs, so the keys of the config file that are never read can be listed with UnreadKeys
+var TraceAccess = false
+
+// access holds the keys that were read while TraceAccess is set. Reading a key reads the keys below it.
+var access = struct {
+	sync.Mutex
+	keys map[string]bool
+}{}
+
+// traceRead records that the key was read, where the empty key reads the whole config
+func traceRead(key string) {
+	if !TraceAccess {
+		return
+	}
+	access.Lock()
+	defer access.Unlock()
+	if access.keys == nil {
+		access.keys = make(map[string]bool)
+	}
+	access.keys[strings.ToLower(key)] = true
+}
+
+// resetTrace clears the keys that were read
+func resetTrace() {
+	access.Lock()
+	access.keys = nil
+	access.Unlock()
+}
+
+// ReadKeys returns the keys that were read while TraceAccess was set, sorted
+func ReadKeys() []string {
+	access.Lock()
+	defer access.Unlock()
+	keys := make([]string, 0, len(access.keys))
+	for key := range access.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// wasRead reports whether the key or a key above it was read
+func wasRead(key string) bool {
+	access.Lock()
+	defer access.Unlock()
+	if access.keys[""] {
+		return true
+	}
+	for {
+		if access.keys[key] {
+			return true
+		}
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			return false
+		}
+		key = key[:i]
+	}
+}
+
+// UnreadKeys returns the keys of the config file that weren't read while TraceAccess was set, sorted
+func UnreadKeys() []string {
+	loadConfig()
+	mu.RLock()
+	file := viper.ConfigFileUsed()
+	mu.RUnlock()
+	var keys []string
+	for key := range fileSettings(file) {
+		if !wasRead(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PrintUnreadKeys writes the keys of the config file that weren't read
+func PrintUnreadKeys(w io.Writer) {
+	keys := UnreadKeys()
+	if len(keys) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Unread config keys (%s):\n", configFile())
+	for _, key := range keys {
+		fmt.Fprintf(w, "  %s\n", key)
+	}
+}
+
+// AddTraceFlag adds the persistent --trace-config flag that sets TraceAccess when the flags are parsed,
+// before any bound Struct is resolved, and prints the unread config keys after the command ran
+func AddTraceFlag(c *cobra.Command) {
+	c.PersistentFlags().BoolVar(&TraceAccess, "trace-config", TraceAccess, "Print the config keys that weren't read")
+	cobrahooks.OnPersistentPostRun(c, func(cmd *cobra.Command, args []string) error {
+		if TraceAccess {
+			PrintUnreadKeys(cmd.ErrOrStderr())
+		}
+		return nil
+	})
+}
@@ -0,0 +1,72 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type accessStruct struct {
+	Name string
+}
+
+func TestTraceAccess(t *testing.T) {
+
+	Reset()
+	defer Reset()
+	defer func() { TraceAccess = false }()
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	data := "accSection:\n  used: yes\n  dead: 1\naccBound:\n  name: bound\n  nmae: typo\n"
+	if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ReadInConfig()
+	viper.SetConfigFile(file)
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	var config accessStruct
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {
+			GetString("accSection.used")
+		},
+	}
+	AddTraceFlag(rootCmd)
+	BindFlags(rootCmd, &config, Key("accBound"))
+
+	output, err := executeCommand(rootCmd, "--trace-config")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if config.Name != "bound" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config.Name, "bound")
+	}
+
+	want := []string{"accbound.nmae", "accsection.dead"}
+	if got := UnreadKeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, want)
+	}
+
+	if !strings.Contains(output, "Unread config keys") || !strings.Contains(output, "  accbound.nmae\n  accsection.dead\n") {
+		t.Errorf("Unexpected output:\n%s", output)
+	}
+
+	if got := ReadKeys(); !reflect.DeepEqual(got, []string{"accbound.name", "accsection.used"}) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, []string{"accbound.name", "accsection.used"})
+	}
+}
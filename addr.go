@@ -0,0 +1,73 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ValidateHostPort checks that the address is a host:port like "localhost:8080", "[::1]:53" or ":8080"
+// with a port between 0 and 65535. With scheme, a scheme like "tcp://" or "https://" may precede it.
+func ValidateHostPort(addr string, scheme bool) error {
+	if i := strings.Index(addr, "://"); i >= 0 {
+		if !scheme {
+			return fmt.Errorf("%q must not have a scheme", addr)
+		}
+		if i == 0 {
+			return fmt.Errorf("%q has an empty scheme", addr)
+		}
+		addr = addr[i+3:]
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("%q is not a host:port address", addr)
+	}
+	if strings.ContainsAny(host, " /") {
+		return fmt.Errorf("%q has an invalid host", addr)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("%q has an invalid port, use a number between 0 and 65535", addr)
+	}
+	return nil
+}
+
+// validateAddrs checks the fields with an `addr:"true"` or `addr:"scheme"` tag that are not empty
+func validateAddrs(rawVal interface{}, opts *BindOptions) error {
+	rv := structValue(rawVal)
+	for _, f := range structFields(rv.Type()) {
+		if !f.addr {
+			continue
+		}
+		if value := rv.Field(f.index).String(); value != "" {
+			if err := ValidateHostPort(value, f.addrScheme); err != nil {
+				return fieldError(f, opts, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// validateBindingAddrs checks the addr tags of a bound Struct decoded from edited settings
+func validateBindingAddrs(rv reflect.Value, b schemaBinding) error {
+	for _, f := range structFields(b.rt) {
+		if !f.addr {
+			continue
+		}
+		if value := rv.Field(f.index).String(); value != "" {
+			if err := ValidateHostPort(value, f.addrScheme); err != nil {
+				key := strings.ToLower(b.keyName(f.name))
+				if b.key != "" {
+					key = b.key + "." + key
+				}
+				return &ErrValidation{Field: f.name, Key: key, Rule: err.Error()}
+			}
+		}
+	}
+	return nil
+}
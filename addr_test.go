@@ -0,0 +1,54 @@
+package cfg
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestValidateHostPort(t *testing.T) {
+
+	for _, addr := range []string{"localhost:8080", ":8080", "[::1]:53", "10.0.0.1:0", "example.com:65535"} {
+		if err := ValidateHostPort(addr, false); err != nil {
+			t.Errorf("ValidateHostPort(%q): %v", addr, err)
+		}
+	}
+
+	if err := ValidateHostPort("tcp://localhost:8080", true); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	for _, addr := range []string{"localhost", "localhost:http", "localhost:65536", "::1:53", "tcp://localhost:8080", "local host:80"} {
+		if err := ValidateHostPort(addr, false); err == nil {
+			t.Errorf("ValidateHostPort(%q): expected an error", addr)
+		}
+	}
+}
+
+type addrStruct struct {
+	Listen   string `addr:"true"`
+	Upstream string `addr:"scheme"`
+}
+
+func TestBindAddr(t *testing.T) {
+
+	var config addrStruct
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, NoViper)
+
+	if _, err := executeCommand(rootCmd, "--listen", ":8080", "--upstream", "https://backend:443"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_, err := executeCommand(rootCmd, "--listen", "8080")
+	var validationErr *ErrValidation
+	if !errors.As(err, &validationErr) || err.Error() != `invalid value --listen: "8080" is not a host:port address` {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// WarningOutput receives warnings emitted while loading the config
+var WarningOutput io.Writer = os.Stderr
+
+type alias struct {
+	oldKey string
+	newKey string
+}
+
+// aliases holds the registered aliases
+var aliases struct {
+	sync.Mutex
+	list []alias
+}
+
+// RegisterAlias registers a renamed key. Values found under the old key in the config file are migrated
+// into the new key when the config is loaded and a deprecation warning is printed.
+func RegisterAlias(oldKey string, newKey string) {
+	a := alias{oldKey: oldKey, newKey: newKey}
+	aliases.Lock()
+	aliases.list = append(aliases.list, a)
+	aliases.Unlock()
+	if !loaded {
+		return
+	}
+	mu.Lock()
+	settings := deepCopy(layers.file).(map[string]interface{})
+	if a.apply(settings, viper.ConfigFileUsed()) {
+		layers.file = settings
+		rebuildConfig()
+	}
+	mu.Unlock()
+	resetCache()
+}
+
+// applyAliases migrates the values of all registered aliases in the settings of the config file
+func applyAliases(settings map[string]interface{}, file string) {
+	aliases.Lock()
+	list := aliases.list
+	aliases.Unlock()
+	for _, a := range list {
+		a.apply(settings, file)
+	}
+}
+
+// apply copies the value of the old key to the new key unless it is set and reports whether the old key was found
+func (a alias) apply(settings map[string]interface{}, file string) bool {
+	value, ok := lookupKey(settings, a.oldKey)
+	if !ok {
+		return false
+	}
+	fmt.Fprintf(WarningOutput, "Warning: config key %q is deprecated, use %q instead (%s)\n", a.oldKey, a.newKey, file)
+	if _, ok := lookupKey(settings, a.newKey); !ok {
+		setPath(settings, strings.Split(strings.ToLower(a.newKey), "."), deepCopy(value))
+	}
+	return true
+}
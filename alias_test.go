@@ -0,0 +1,33 @@
+package cfg
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRegisterAlias(t *testing.T) {
+
+	Reset()
+	t.Cleanup(Reset)
+	out := new(bytes.Buffer)
+	defer func(w io.Writer) { WarningOutput = w }(WarningOutput)
+	WarningOutput = out
+
+	loadConfig()
+	RegisterAlias("ninthParam", "renamed.ninthParam")
+	RegisterAlias("missingParam", "renamed.missingParam")
+
+	if got := GetString("renamed.ninthParam"); got != "Ninth" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "Ninth")
+	}
+
+	if !strings.HasPrefix(out.String(), `Warning: config key "ninthParam" is deprecated, use "renamed.ninthParam" instead`) {
+		t.Errorf("Unexpected warning: %q", out.String())
+	}
+
+	if strings.Contains(out.String(), "missingParam") {
+		t.Errorf("Unexpected warning: %q", out.String())
+	}
+}
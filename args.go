@@ -0,0 +1,59 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// argName returns the name of the positional argument shown in errors
+func (o *BindOptions) argName(f fieldInfo) string {
+	if f.arg != "" {
+		return f.arg
+	}
+	return o.flagName(f.name)
+}
+
+// setArgs sets the fields with an `arg` tag from the positional arguments.
+// Arguments override the config and flags and are parsed like flag values, including the choices tag.
+func setArgs(rawVal interface{}, args []string, opts *BindOptions) error {
+	rv := structValue(rawVal)
+	for _, f := range structFields(rv.Type()) {
+		if f.argIndex < 0 || f.argIndex >= len(args) {
+			continue
+		}
+		if f.variadic {
+			if err := setArgSlice(rv.Field(f.index), args[f.argIndex:], f.choices); err != nil {
+				return fmt.Errorf("argument <%s>: %w", opts.argName(f), err)
+			}
+			continue
+		}
+		switch f.kind {
+		case reflect.Bool, reflect.String, reflect.Float64, reflect.Int:
+			if err := setFieldString(rv.Field(f.index), args[f.argIndex], f.choices); err != nil {
+				return fmt.Errorf("argument <%s>: %w", opts.argName(f), err)
+			}
+		}
+	}
+	return nil
+}
+
+// setArgSlice parses the arguments into the elements of a new slice
+func setArgSlice(fv reflect.Value, args []string, choices []string) error {
+	switch fv.Type().Elem().Kind() {
+	case reflect.Bool, reflect.String, reflect.Float64, reflect.Int:
+	default:
+		return fmt.Errorf("unsupported argument type %s", fv.Type())
+	}
+	s := reflect.MakeSlice(fv.Type(), len(args), len(args))
+	for i, arg := range args {
+		if err := setFieldString(s.Index(i), arg, choices); err != nil {
+			return err
+		}
+	}
+	fv.Set(s)
+	return nil
+}
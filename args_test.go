@@ -0,0 +1,112 @@
+package cfg
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+type argsStruct struct {
+	Source string `arg:"source" required:"true"`
+	Port   int    `arg:"1"`
+	Mode   string `choices:"fast,safe"`
+}
+
+func TestBindArgs(t *testing.T) {
+
+	var config argsStruct
+
+	rootCmd := &cobra.Command{
+		Use:  "root <source> [port]",
+		Args: cobra.MaximumNArgs(2),
+		Run:  func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, NoViper)
+
+	if rootCmd.Flags().Lookup("source") != nil {
+		t.Errorf("Expected no flag for an argument field")
+	}
+
+	if _, err := executeCommand(rootCmd, "src", "8080", "--mode", "safe"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if want := (argsStruct{Source: "src", Port: 8080, Mode: "safe"}); config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+
+	if _, err := executeCommand(rootCmd, "src", "http"); err == nil || err.Error() != `argument <port>: invalid integer "http"` {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestBindArgsRequired(t *testing.T) {
+
+	var config argsStruct
+
+	rootCmd := &cobra.Command{
+		Use: "root <source> [port]",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, NoViper)
+
+	_, err := executeCommand(rootCmd)
+
+	var validationErr *ErrValidation
+	if !errors.As(err, &validationErr) || err.Error() != "missing required argument <source>" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+type variadicArgsStruct struct {
+	Action  string   `arg:"action" choices:"build,test"`
+	Targets []string `arg:"targets..."`
+}
+
+func TestBindVariadicArgs(t *testing.T) {
+
+	var config variadicArgsStruct
+
+	rootCmd := &cobra.Command{
+		Use: "root <action> <targets...>",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, NoViper)
+
+	if _, err := executeCommand(rootCmd, "build", "api", "web"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if want := []string{"api", "web"}; !reflect.DeepEqual(config.Targets, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config.Targets, want)
+	}
+}
+
+type typedVariadicArgsStruct struct {
+	Ports []int `arg:"0..."`
+}
+
+func TestBindTypedVariadicArgs(t *testing.T) {
+
+	var config typedVariadicArgsStruct
+
+	rootCmd := &cobra.Command{
+		Use: "root <ports...>",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, NoViper)
+
+	if _, err := executeCommand(rootCmd, "80", "443"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if want := []int{80, 443}; !reflect.DeepEqual(config.Ports, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config.Ports, want)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// flagBinder links a generated flag to its Struct field
+type flagBinder struct {
+	flag   *pflag.Flag
+	field  fieldInfo
+	format func(reflect.Value) string
+}
+
+// newFlagBinders looks up the flags of the Struct fields once, so the hooks don't
+// look them up and format their defaults through reflection on every execution
+func newFlagBinders(flags *pflag.FlagSet, rawVal interface{}, flagName NamingFunc) []flagBinder {
+	rv := structValue(rawVal)
+	var binders []flagBinder
+	for _, f := range structFields(rv.Type()) {
+		flag := flags.Lookup(flagName(f.name))
+		if flag == nil {
+			continue
+		}
+		binders = append(binders, flagBinder{flag: flag, field: f, format: formatFunc(f)})
+	}
+	return binders
+}
+
+// formatFunc returns the function formatting the field value as flag default
+func formatFunc(f fieldInfo) func(reflect.Value) string {
+	if f.typ == percentType {
+		return func(v reflect.Value) string { return v.Interface().(Percent).String() }
+	}
+	switch f.kind {
+	case reflect.Bool:
+		return func(v reflect.Value) string { return strconv.FormatBool(v.Bool()) }
+	case reflect.String:
+		return func(v reflect.Value) string { return v.String() }
+	case reflect.Int:
+		return func(v reflect.Value) string { return strconv.FormatInt(v.Int(), 10) }
+	case reflect.Float64:
+		return func(v reflect.Value) string { return strconv.FormatFloat(v.Float(), 'g', -1, 64) }
+	case reflect.Slice:
+		if f.typ.Elem().Kind() == reflect.String {
+			return func(v reflect.Value) string {
+				return "[" + strings.Join(v.Interface().([]string), ",") + "]"
+			}
+		}
+	}
+	return func(v reflect.Value) string { return fmt.Sprintf("%v", v.Interface()) }
+}
+
+// setBinderDefaults sets the Struct values as flag defaults
+func setBinderDefaults(rv reflect.Value, binders []flagBinder) {
+	for _, b := range binders {
+		b.flag.DefValue = b.format(rv.Field(b.field.index))
+	}
+}
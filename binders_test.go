@@ -0,0 +1,46 @@
+package cfg
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+type binderStruct struct {
+	Enabled bool
+	Name    string
+	Count   int
+	Ratio   float64
+	Tags    []string
+}
+
+func TestSetBinderDefaults(t *testing.T) {
+
+	config := binderStruct{Enabled: true, Name: "name", Count: -3, Ratio: 0.25, Tags: []string{"a", "b"}}
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	CreateFlags(flags, &config)
+
+	binders := newFlagBinders(flags, &config, FlagNaming)
+	if len(binders) != 5 {
+		t.Fatalf("\ngot:  %v\nwant: %v\n", len(binders), 5)
+	}
+
+	config = binderStruct{Name: "other", Count: 12, Ratio: 1e21, Tags: []string{"c"}}
+	setBinderDefaults(reflect.ValueOf(config), binders)
+
+	want := map[string]string{
+		"enabled": "false",
+		"name":    "other",
+		"count":   "12",
+		"ratio":   fmt.Sprintf("%v", 1e21),
+		"tags":    "[c]",
+	}
+
+	for name, def := range want {
+		if got := flags.Lookup(name).DefValue; got != def {
+			t.Errorf("%s\ngot:  %v\nwant: %v\n", name, got, def)
+		}
+	}
+}
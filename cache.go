@@ -0,0 +1,105 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// cache holds the config settings by key and the decoded collections for the current command execution,
+// so commands with several bindings read the config tree only once
+var cache = struct {
+	sync.Mutex
+	settings    map[string]map[string]interface{}
+	collections map[string]*indexedCollection
+}{}
+
+func init() {
+	cobra.OnInitialize(resetCache)
+}
+
+// resetCache clears the cached settings and collections.
+// It runs at the start of every Cobra command execution and whenever the config changes through cfg.
+func resetCache() {
+	cache.Lock()
+	defer cache.Unlock()
+	cache.settings = nil
+	cache.collections = nil
+}
+
+// cachedSettings returns the settings at the key, or all settings when the key is empty
+func cachedSettings(key string) map[string]interface{} {
+	cache.Lock()
+	defer cache.Unlock()
+	if settings, ok := cache.settings[key]; ok {
+		return settings
+	}
+	var settings map[string]interface{}
+	mu.RLock()
+	if key == "" {
+		settings = viper.AllSettings()
+	} else {
+		settings = sectionSettings(key)
+	}
+	mu.RUnlock()
+	if cache.settings == nil {
+		cache.settings = make(map[string]map[string]interface{})
+	}
+	cache.settings[key] = settings
+	return settings
+}
+
+// indexedCollection is a decoded collection with its items indexed by id
+type indexedCollection struct {
+	items []map[string]interface{}
+	index map[string]map[string]int // id field -> id -> position
+}
+
+// cachedCollection returns the decoded collection at collField
+func cachedCollection(collField string) (*indexedCollection, error) {
+	traceRead(collField)
+	cache.Lock()
+	defer cache.Unlock()
+	if c, ok := cache.collections[collField]; ok {
+		return c, nil
+	}
+	c := &indexedCollection{index: make(map[string]map[string]int)}
+	mu.RLock()
+	value := viper.Get(collField)
+	mu.RUnlock()
+	if err := mapstructure.Decode(value, &c.items); err != nil {
+		return nil, err
+	}
+	if cache.collections == nil {
+		cache.collections = make(map[string]*indexedCollection)
+	}
+	cache.collections[collField] = c
+	return c, nil
+}
+
+// lookup returns the position of the item where idField equals value or -1
+func (c *indexedCollection) lookup(idField string, value string) int {
+	cache.Lock()
+	defer cache.Unlock()
+	index, ok := c.index[idField]
+	if !ok {
+		index = make(map[string]int, len(c.items))
+		for i := len(c.items) - 1; i >= 0; i-- {
+			if id, ok := c.items[i][idField]; ok {
+				index[fmt.Sprintf("%v", id)] = i
+			}
+		}
+		c.index[idField] = index
+	}
+	if i, ok := index[value]; ok {
+		return i
+	}
+	return -1
+}
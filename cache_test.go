@@ -0,0 +1,98 @@
+package cfg
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type cachedStruct struct {
+	Name string
+}
+
+func TestRunBoundCommandCachedSettings(t *testing.T) {
+
+	var first, second cachedStruct
+
+	Set("cachedSection", map[string]interface{}{"name": "First"})
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindPersistentFlags(rootCmd, &first, Key("cachedSection"))
+	BindFlags(rootCmd, &second, Key("cachedSection"), FlagNames(func(name string) string {
+		return "second-" + KebabCase(name)
+	}))
+
+	if _, err := executeCommand(rootCmd); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if first.Name != "First" || second.Name != "First" {
+		t.Errorf("\ngot:  %v %v\nwant: %v\n", first, second, "First")
+	}
+
+	if _, ok := cache.settings["cachedSection"]; !ok {
+		t.Errorf("Expected the settings to be cached")
+	}
+
+	// Changing viper directly is picked up by the next execution
+	viper.Set("cachedSection", map[string]interface{}{"name": "Second"})
+
+	if _, err := executeCommand(rootCmd); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if first.Name != "Second" || second.Name != "Second" {
+		t.Errorf("\ngot:  %v %v\nwant: %v\n", first, second, "Second")
+	}
+}
+
+func TestRunBoundCollectionCachedCollection(t *testing.T) {
+
+	var first, second envStruct
+
+	Set("cachedEnvs", []map[string]interface{}{
+		{"name": "prod", "region": "us-east-1"},
+		{"name": "staging", "region": "eu-central-1"},
+	})
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	childCmd := &cobra.Command{
+		Use: "child",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	rootCmd.AddCommand(childCmd)
+
+	BindCollectionItem(rootCmd, &first, CollectionField("cachedEnvs"), SelectValue("staging"))
+	BindCollectionItem(childCmd, &second, CollectionField("cachedEnvs"), SelectValue("prod"))
+
+	if _, err := executeCommand(rootCmd, "child"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if want := (envStruct{Name: "prod", Region: "us-east-1"}); second != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", second, want)
+	}
+
+	c, ok := cache.collections["cachedEnvs"]
+	if !ok {
+		t.Fatalf("Expected the collection to be cached")
+	}
+
+	if i := c.lookup("name", "staging"); i != 1 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", i, 1)
+	}
+
+	if i := c.lookup("name", "missing"); i != -1 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", i, -1)
+	}
+}
@@ -5,12 +5,16 @@
 package cfg
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/bartdeboer/cobrahooks"
 	"github.com/iancoleman/strcase"
@@ -24,21 +28,81 @@ import (
 
 func Get(key string) interface{} {
 	loadConfig()
-	return viper.Get(key)
+	traceRead(key)
+	mu.RLock()
+	defer mu.RUnlock()
+	return getValue(key)
 }
 
 func GetInt(key string) int {
 	loadConfig()
+	traceRead(key)
+	mu.RLock()
+	defer mu.RUnlock()
 	return viper.GetInt(key)
 }
 
 func GetString(key string) string {
 	loadConfig()
+	traceRead(key)
+	mu.RLock()
+	defer mu.RUnlock()
 	return viper.GetString(key)
 }
 
+// GetOr returns the value of the key converted to the type of the fallback,
+// or the fallback when the key isn't set or can't be converted. Values explicitly set to zero are returned.
+func GetOr[T any](key string, fallback T) T {
+	loadConfig()
+	traceRead(key)
+	mu.RLock()
+	set, value := viper.IsSet(key), getValue(key)
+	mu.RUnlock()
+	if !set {
+		return fallback
+	}
+	if v, ok := value.(T); ok {
+		return v
+	}
+	var v T
+	if err := decode(value, &v); err != nil {
+		return fallback
+	}
+	return v
+}
+
+// GetStringOr returns the string value of the key or the fallback when it isn't set
+func GetStringOr(key string, fallback string) string {
+	return GetOr(key, fallback)
+}
+
+// GetIntOr returns the int value of the key or the fallback when it isn't set
+func GetIntOr(key string, fallback int) int {
+	return GetOr(key, fallback)
+}
+
+// GetAllWithPrefix returns the merged values of all keys starting with the prefix, like "feature.".
+// Keys are lowercase and include the prefix.
+func GetAllWithPrefix(prefix string) map[string]interface{} {
+	loadConfig()
+	mu.RLock()
+	defer mu.RUnlock()
+	prefix = strings.ToLower(prefix)
+	traceRead(strings.TrimSuffix(prefix, "."))
+	m := make(map[string]interface{})
+	for _, key := range allKeys() {
+		if strings.HasPrefix(key, prefix) {
+			m[key] = viper.Get(key)
+		}
+	}
+	return m
+}
+
 func Set(key string, value interface{}) {
-	viper.Set(key, value)
+	mu.Lock()
+	setOverride(key, value)
+	mu.Unlock()
+	resetCache()
 }
 
 func ReadInConfig() {
@@ -48,73 +112,507 @@ func ReadInConfig() {
 // Unmashal unmarshals the config into a Struct overriding with any flags that are set
 func Unmarshal(rawVal interface{}, opts ...viper.DecoderConfigOption) error {
 	loadConfig()
+	traceRead("")
 	curVal := getPtrValue(rawVal)
-	if err := viper.Unmarshal(rawVal, opts...); err != nil {
-		return err
-	}
-	if err := mergo.MergeWithOverwrite(rawVal, curVal); err != nil {
-		return err
+	mu.RLock()
+	err := viper.Unmarshal(rawVal, decodeHooks(opts)...)
+	mu.RUnlock()
+	if err != nil {
+		return &ErrDecode{Type: reflect.TypeOf(rawVal).Elem().String(), Err: err}
 	}
-	return nil
+	return mergeValue(rawVal, curVal)
 }
 
-// Unmashal takes a single key and unmarshals it into a Struct overriding with any flags that are set
+// Unmashal takes a single key and unmarshals it into a Struct overriding with any flags that are set.
+// Maps and primitives can be unmarshalled as well, keeping the entries or the non-zero value they already had.
+// A slice with an empty key is decoded from a config file with a list root, like UnmarshalRootSlice.
 func UnmarshalKey(key string, rawVal interface{}, opts ...viper.DecoderConfigOption) error {
+	if key == "" && reflect.TypeOf(rawVal).Elem().Kind() == reflect.Slice {
+		return UnmarshalRootSlice(rawVal, opts...)
+	}
 	loadConfig()
+	traceRead(key)
 	curVal := getPtrValue(rawVal)
-	if err := viper.UnmarshalKey(key, rawVal, opts...); err != nil {
+	mu.RLock()
+	err := decode(getValue(key), rawVal, decodeHooks(opts)...)
+	mu.RUnlock()
+	if err != nil {
+		return &ErrDecode{Key: key, Type: reflect.TypeOf(rawVal).Elem().String(), Value: Get(key), Err: err}
+	}
+	return mergeValue(rawVal, curVal)
+}
+
+// unmarshalNamed unmarshals the config into a Struct matching the keys by their names
+// and overrides with any flags that are set
+func unmarshalNamed(key string, rawVal interface{}, keyName NamingFunc, opts ...viper.DecoderConfigOption) error {
+	loadConfig()
+	mu.RLock()
+	settings := viper.AllSettings()
+	if key != "" {
+		settings = sectionSettings(key)
+	}
+	mu.RUnlock()
+	curVal := getPtrValue(rawVal)
+	if err := decodeNamed(settings, rawVal, keyName, opts...); err != nil {
 		return err
 	}
-	if err := mergo.MergeWithOverwrite(rawVal, curVal); err != nil {
+	return mergo.MergeWithOverwrite(rawVal, curVal)
+}
+
+// decodeNamed decodes the settings into a Struct matching the keys by their names
+func decodeNamed(settings map[string]interface{}, rawVal interface{}, keyName NamingFunc, opts ...viper.DecoderConfigOption) error {
+	rt := reflect.TypeOf(rawVal).Elem()
+	input := make(map[string]interface{})
+	for i := 0; i < rt.NumField(); i++ {
+		name := rt.Field(i).Name
+		for k, v := range settings {
+			if strings.EqualFold(k, keyName(name)) {
+				input[name] = v
+			}
+		}
+	}
+	return decode(input, rawVal, opts...)
+}
+
+// decode decodes the input into the output the same way viper does
+func decode(input interface{}, output interface{}, opts ...viper.DecoderConfigOption) error {
+	c := &mapstructure.DecoderConfig{
+		Result:           output,
+		Metadata:         nil,
+		WeaklyTypedInput: true,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			durationHook(),
+			percentHook,
+			secretHook,
+			mapstructure.StringToSliceHookFunc(","),
+		),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	decoder, err := mapstructure.NewDecoder(c)
+	if err != nil {
 		return err
 	}
-	return nil
+	return decoder.Decode(input)
 }
 
 // getPtrValue Gets the real struct value of a pointer
+// Maps are copied, because the config is decoded into the existing map.
 func getPtrValue(i interface{}) interface{} {
 	rvp := reflect.ValueOf(i)
 	if k := rvp.Kind(); k != reflect.Ptr {
 		panic("Value is not a pointer")
 	}
 	rv := rvp.Elem() // struct value from pointer
-	if k := rv.Kind(); k != reflect.Struct && k != reflect.Slice {
-		panic("Value is not a struct or slice")
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Slice:
+	case reflect.Map:
+		if rv.IsNil() {
+			return rv.Interface()
+		}
+		m := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		for iter := rv.MapRange(); iter.Next(); {
+			m.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return m.Interface()
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		panic("Value is not a struct, slice, map or primitive")
 	}
 	return rv.Interface() // Get real value of Value
 }
 
+// mergeValue overrides the decoded value with the non-empty values it had before decoding.
+// Map entries are merged, primitives are kept when they are not zero.
+func mergeValue(rawVal interface{}, curVal interface{}) error {
+	cv := reflect.ValueOf(curVal)
+	switch cv.Kind() {
+	case reflect.Struct, reflect.Slice:
+		return mergo.MergeWithOverwrite(rawVal, curVal)
+	case reflect.Map:
+		rv := reflect.ValueOf(rawVal).Elem()
+		if cv.Len() > 0 && rv.IsNil() {
+			rv.Set(reflect.MakeMapWithSize(rv.Type(), cv.Len()))
+		}
+		for iter := cv.MapRange(); iter.Next(); {
+			rv.SetMapIndex(iter.Key(), iter.Value())
+		}
+	default:
+		if !cv.IsZero() {
+			reflect.ValueOf(rawVal).Elem().Set(cv)
+		}
+	}
+	return nil
+}
+
 type BindOptions struct {
-	noViper bool
-	key     string
+	noViper       bool
+	key           string
+	promptMissing bool
+	bindPFlags    bool
+	saveFlag      bool
+	scoped        bool
+	keyFromType   bool
+	flagNaming    NamingFunc
+	keyNaming     NamingFunc
+	keyMap        map[string]string
+	strict        bool
+	strictKeys    bool
+	defaults      interface{}
+	sliceMerge    SliceMerge
+	replaceMaps   []string
+	binders       []flagBinder
+	toContext     bool
+	config        *Config
+	args          []string
+}
+
+// NamingFunc converts a Struct field name into a flag name
+type NamingFunc func(string) string
+
+var (
+	KebabCase NamingFunc = strcase.ToKebab
+	SnakeCase NamingFunc = strcase.ToSnake
+	CamelCase NamingFunc = strcase.ToLowerCamel
+)
+
+// FlagNaming converts field names into flag names for bindings without FlagNames
+var FlagNaming = KebabCase
+
+// SortFlags lists generated flags alphabetically in help output.
+// By default they are listed in the order of the Struct fields, and in binding order across Structs.
+var SortFlags = false
+
+// FlagNames sets the naming of the generated flags for the binding
+func FlagNames(f NamingFunc) func(*BindOptions) {
+	return func(o *BindOptions) {
+		o.flagNaming = f
+	}
+}
+
+// KeyNaming converts field names into config keys for bindings without KeyNames.
+// When nil, fields match keys case-insensitively by their name.
+var KeyNaming NamingFunc
+
+// KeyNames sets the naming of the config keys for the binding independent of the flag names
+func KeyNames(f NamingFunc) func(*BindOptions) {
+	return func(o *BindOptions) {
+		o.keyNaming = f
+	}
+}
+
+// KeyMap maps Struct field names to config keys, taking precedence over the key naming
+func KeyMap(m map[string]string) func(*BindOptions) {
+	return func(o *BindOptions) {
+		o.keyMap = m
+	}
+}
+
+// hasKeyNaming reports whether config keys differ from the field names
+func (o *BindOptions) hasKeyNaming() bool {
+	return o.keyMap != nil || o.keyNaming != nil || KeyNaming != nil
+}
+
+// keyName returns the config key for a Struct field
+func (o *BindOptions) keyName(field string) string {
+	if key, ok := o.keyMap[field]; ok {
+		return key
+	}
+	if o.keyNaming != nil {
+		return o.keyNaming(field)
+	}
+	if KeyNaming != nil {
+		return KeyNaming(field)
+	}
+	return field
+}
+
+// flagName returns the flag name for a Struct field
+func (o *BindOptions) flagName(field string) string {
+	if o.flagNaming != nil {
+		return o.flagNaming(field)
+	}
+	return FlagNaming(field)
+}
+
+// newBindOptions applies the options for binding the Struct
+func newBindOptions(rawVal interface{}, options []func(*BindOptions)) BindOptions {
+	var opts BindOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.keyFromType && opts.key == "" {
+		opts.key = typeKey(rawVal)
+	}
+	return opts
 }
 
 func NoViper(o *BindOptions) { o.noViper = true }
 
+// StrictTypes requires config values to have the exact type of the Struct fields
+// instead of coercing them, so "8080x" fails for an int field instead of guessing.
+// Note that values from environment variables are always strings.
+func StrictTypes(o *BindOptions) { o.strict = true }
+
+// StrictKeys fails the binding with an ErrUnknownKey, suggesting the nearest field key,
+// when the config at the key has keys that are not fields of the Struct
+func StrictKeys(o *BindOptions) { o.strictKeys = true }
+
+// StrictDecoding is a decoder option for Unmarshal and UnmarshalKey that disables weak type coercion
+func StrictDecoding(c *mapstructure.DecoderConfig) { c.WeaklyTypedInput = false }
+
+// decoderOptions returns the decoder options for the binding
+func (o *BindOptions) decoderOptions() []viper.DecoderConfigOption {
+	opts := []viper.DecoderConfigOption{viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		unsetHook,
+		durationHook(),
+		percentHook,
+		secretHook,
+		mapstructure.StringToSliceHookFunc(","),
+	))}
+	if o.strict {
+		opts = append(opts, StrictDecoding)
+	}
+	return opts
+}
+
 func Key(key string) func(*BindOptions) {
 	return func(o *BindOptions) {
 		o.key = key
 	}
 }
 
+// KeyFromType derives the key from the Struct type name when no Key is given.
+// Suffixes like Config and Settings are dropped, so ServerConfig binds to the key "server".
+func KeyFromType(o *BindOptions) { o.keyFromType = true }
+
+// typeKeySuffixes are dropped from type names when deriving keys
+var typeKeySuffixes = []string{"Configuration", "Config", "Settings", "Options"}
+
+// typeKey derives a key from the type name of a Struct pointer
+func typeKey(rawVal interface{}) string {
+	name := reflect.TypeOf(rawVal).Elem().Name()
+	for _, suffix := range typeKeySuffixes {
+		if name != suffix && strings.HasSuffix(name, suffix) {
+			name = strings.TrimSuffix(name, suffix)
+			break
+		}
+	}
+	return strcase.ToLowerCamel(name)
+}
+
+// ScopeByCommand derives the key from the command path, excluding the root command.
+// For example `root child1 child2` binds to the key "child1.child2".
+// A Key is nested below the derived key.
+func ScopeByCommand(o *BindOptions) { o.scoped = true }
+
+// forCommand returns the options with the key scoped to the command
+func (o BindOptions) forCommand(c *cobra.Command) BindOptions {
+	if !o.scoped {
+		return o
+	}
+	var names []string
+	for p := c; p.HasParent(); p = p.Parent() {
+		names = append([]string{p.Name()}, names...)
+	}
+	if o.key != "" {
+		names = append(names, o.key)
+	}
+	o.key = strings.Join(names, ".")
+	return o
+}
+
+// BindPFlags binds the generated flags to their viper keys so viper.Get reflects flag overrides
+func BindPFlags(o *BindOptions) { o.bindPFlags = true }
+
+// SaveFlag adds a --save flag that writes the flag values that are set to the config file
+func SaveFlag(o *BindOptions) { o.saveFlag = true }
+
+// createSaveFlag adds the --save flag unless another binding already added it
+func createSaveFlag(flags *pflag.FlagSet, opts *BindOptions) {
+	if opts.saveFlag && flags.Lookup("save") == nil {
+		flags.Bool("save", false, "Save the flag values to the config file")
+	}
+}
+
+// SaveOverrides writes the values of the flags that are set to the config file under the bound key
+func SaveOverrides(flags *pflag.FlagSet, rawVal interface{}, options ...func(*BindOptions)) error {
+	opts := newBindOptions(rawVal, options)
+	return saveOverrides(flags, rawVal, &opts)
+}
+
+func saveOverrides(flags *pflag.FlagSet, rawVal interface{}, opts *BindOptions) error {
+	changed := changedFields(flags, rawVal, opts.flagName, opts.keyName)
+	if len(changed) == 0 {
+		return nil
+	}
+	for k, v := range changed {
+		if opts.key != "" {
+			k = opts.key + "." + k
+		}
+		opts.set(k, v)
+	}
+	return opts.write()
+}
+
+// bindPFlags binds the flags of the Struct fields to the viper keys of the fields
+func bindPFlags(flags *pflag.FlagSet, rawVal interface{}, opts *BindOptions) error {
+	key, flagName, keyName := opts.key, opts.flagName, opts.keyName
+	rt := reflect.TypeOf(rawVal).Elem()
+	for i := 0; i < rt.NumField(); i++ {
+		ft := rt.Field(i)
+		flag := flags.Lookup(flagName(ft.Name))
+		if flag == nil {
+			continue
+		}
+		fieldKey := keyName(ft.Name)
+		if key != "" {
+			fieldKey = key + "." + fieldKey
+		}
+		if err := opts.bindPFlag(fieldKey, flag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resolve unmarshals the config into a Struct overriding with any flags that are set
+// and sets the resulting values as flag defaults.
+// Use with CreateFlags to bind a Struct to a FlagSet without Cobra, calling Resolve after parsing.
+func Resolve(flags *pflag.FlagSet, rawVal interface{}, options ...func(*BindOptions)) error {
+	opts := newBindOptions(rawVal, options)
+	opts.args = flags.Args()
+	resetCache()
+	return resolve(flags, rawVal, &opts, os.Stdin, os.Stderr)
+}
+
+// resolve merges the config into the Struct and checks the required fields.
+// Without input the required fields are not checked.
+func resolve(flags *pflag.FlagSet, rawVal interface{}, opts *BindOptions, in io.Reader, out io.Writer) error {
+	if opts.bindPFlags {
+		if err := bindPFlags(flags, rawVal, opts); err != nil {
+			return err
+		}
+	}
+	if !opts.noViper {
+		end := startSpan("cfg.unmarshal")
+		err := opts.unmarshal(flags, rawVal)
+		end(err)
+		if err != nil {
+			return err
+		}
+	}
+	if opts.binders != nil {
+		setBinderDefaults(structValue(rawVal), opts.binders)
+	} else {
+		setFlagDefaults(flags, rawVal, opts.flagName)
+	}
+	if err := setArgs(rawVal, opts.args, opts); err != nil {
+		return err
+	}
+	if save, _ := flags.GetBool("save"); save && opts.saveFlag {
+		if err := saveOverrides(flags, rawVal, opts); err != nil {
+			return err
+		}
+	}
+	if err := resolvePaths(flags, rawVal, opts); err != nil {
+		return err
+	}
+	if err := validateAddrs(rawVal, opts); err != nil {
+		return err
+	}
+	if in == nil {
+		return nil
+	}
+	return resolveRequired(in, out, rawVal, opts)
+}
+
+// unmarshal decodes the config into the Struct and overrides with the flags that were passed.
+// Layers are applied by presence instead of by value: the Struct defaults, then the keys that are
+// set in the config, even to a zero value, and then the flags that Changed on the command line.
+func (o *BindOptions) unmarshal(flags *pflag.FlagSet, rawVal interface{}) error {
+	loadConfig()
+	rv := reflect.ValueOf(rawVal).Elem()
+	flagVal := reflect.ValueOf(getPtrValue(rawVal))
+	if o.defaults != nil {
+		rv.Set(reflect.ValueOf(o.defaults))
+	}
+	cloneFields(rv)
+	o.clearReplaced(rv, "")
+	settings := o.settings()
+	o.traceFields(rv.Type())
+	o.clearUnset(rv, settings, true)
+	var err error
+	if o.hasKeyNaming() {
+		err = decodeNamed(settings, rawVal, o.keyName, o.decoderOptions()...)
+	} else {
+		err = decode(settings, rawVal, o.decoderOptions()...)
+	}
+	if err != nil {
+		return &ErrDecode{Key: o.key, Type: rv.Type().String(), Value: settings, Err: err}
+	}
+	if o.strictKeys {
+		if err := o.checkKeys(settings, rv.Type()); err != nil {
+			return err
+		}
+	}
+	binders := o.binders
+	if binders == nil {
+		binders = newFlagBinders(flags, rawVal, o.flagName)
+	}
+	for _, b := range binders {
+		if !b.flag.Changed {
+			continue
+		}
+		i := b.field.index
+		if b.field.kind == reflect.Slice {
+			rv.Field(i).Set(o.sliceMerge.merge(rv.Field(i), flagVal.Field(i)))
+			continue
+		}
+		rv.Field(i).Set(flagVal.Field(i))
+	}
+	return nil
+}
+
+// traceFields records that the keys of the Struct fields of the global config were read
+func (o *BindOptions) traceFields(rt reflect.Type) {
+	if !TraceAccess || o.config != nil {
+		return
+	}
+	for i := 0; i < rt.NumField(); i++ {
+		key := o.keyName(rt.Field(i).Name)
+		if o.key != "" {
+			key = o.key + "." + key
+		}
+		traceRead(key)
+	}
+}
+
 // BindCobraFlags binds a Struct with a viper config when running a Cobra command.
 // Generates Cobra flags for the Struct so they can be overriden.
 func BindFlags(c *cobra.Command, rawVal interface{}, options ...func(*BindOptions)) {
-	var opts BindOptions
-	for _, option := range options {
-		option(&opts)
-	}
-	createFlags(c.Flags(), rawVal)
+	opts := newBindOptions(rawVal, options)
+	opts.defaults = getPtrValue(rawVal)
+	createFlags(c.Flags(), rawVal, opts.flagName)
+	createSaveFlag(c.Flags(), &opts)
+	registerSchema(opts.key, reflect.TypeOf(rawVal).Elem(), opts.keyName)
+	opts.binders = newFlagBinders(c.Flags(), rawVal, opts.flagName)
 	cobrahooks.OnPreRun(c, func(cmd *cobra.Command, args []string) error {
 		fmt.Println("RUN Flags:", c.Use)
-		if !opts.noViper {
-			if opts.key != "" {
-				UnmarshalKey(opts.key, rawVal)
-			} else {
-				Unmarshal(rawVal)
-			}
+		o := opts.forCommand(c)
+		if help, _ := cmd.Flags().GetBool("help"); help {
+			return resolve(c.Flags(), rawVal, &o, nil, nil)
+		}
+		o.args = args
+		if err := resolve(c.Flags(), rawVal, &o, cmd.InOrStdin(), cmd.ErrOrStderr()); err != nil {
+			return err
+		}
+		if o.toContext {
+			return o.storeContext(cmd.Context(), rawVal)
 		}
-		setFlagDefaults(c.Flags(), rawVal)
 		return nil
 	}, cobrahooks.RunOnHelp)
 }
@@ -129,21 +627,26 @@ func BindFlagsKey(key string, c *cobra.Command, rawVal interface{}) {
 // Generates persistent flags for the struct so they can be overriden.
 // Runs the parent persistent hooks as well.
 func BindPersistentFlags(c *cobra.Command, rawVal interface{}, options ...func(*BindOptions)) {
-	var opts BindOptions
-	for _, option := range options {
-		option(&opts)
-	}
-	createFlags(c.PersistentFlags(), rawVal)
+	opts := newBindOptions(rawVal, options)
+	opts.defaults = getPtrValue(rawVal)
+	createFlags(c.PersistentFlags(), rawVal, opts.flagName)
+	c.Flags().SortFlags = SortFlags
+	createSaveFlag(c.PersistentFlags(), &opts)
+	registerSchema(opts.key, reflect.TypeOf(rawVal).Elem(), opts.keyName)
+	opts.binders = newFlagBinders(c.PersistentFlags(), rawVal, opts.flagName)
 	cobrahooks.OnPersistentPreRun(c, func(cmd *cobra.Command, args []string) error {
 		fmt.Println("RUN PersistentFlags:", c.Use)
-		if !opts.noViper {
-			if opts.key != "" {
-				UnmarshalKey(opts.key, rawVal)
-			} else {
-				Unmarshal(rawVal)
-			}
+		o := opts.forCommand(c)
+		if help, _ := cmd.Flags().GetBool("help"); help {
+			return resolve(c.PersistentFlags(), rawVal, &o, nil, nil)
+		}
+		o.args = args
+		if err := resolve(c.PersistentFlags(), rawVal, &o, cmd.InOrStdin(), cmd.ErrOrStderr()); err != nil {
+			return err
+		}
+		if o.toContext {
+			return o.storeContext(cmd.Context(), rawVal)
 		}
-		setFlagDefaults(c.PersistentFlags(), rawVal)
 		return nil
 	}, cobrahooks.RunOnHelp)
 }
@@ -161,6 +664,284 @@ type BindCollectionOptions struct {
 	collection      *[]map[string]interface{}
 	bindTo          interface{}
 	idField         string
+	match           MatchFunc
+	ignoreCase      bool
+	prefix          bool
+	requireItem     bool
+	warnMissing     bool
+	defaultItem     string
+	selectorFlag    bool
+	selector        string
+	multiple        bool
+	parent          *BindCollectionOptions
+	itemCommands    bool
+	itemCmds        map[*cobra.Command]bool
+}
+
+// MatchFunc reports whether the id field value of a collection item matches the selected value
+type MatchFunc func(id interface{}, value string) bool
+
+// matchString compares the string representation of the id with the selected value
+func matchString(id interface{}, value string) bool {
+	return fmt.Sprintf("%v", id) == value
+}
+
+// Match sets a custom MatchFunc for selecting the collection item
+func Match(f MatchFunc) func(*BindCollectionOptions) {
+	return func(o *BindCollectionOptions) {
+		o.match = f
+	}
+}
+
+// IgnoreCase selects the collection item case-insensitively
+func IgnoreCase(o *BindCollectionOptions) { o.ignoreCase = true }
+
+// MatchPrefix selects the first collection item whose id starts with the selected value
+func MatchPrefix(o *BindCollectionOptions) { o.prefix = true }
+
+// DefaultItem selects the collection item with the given id when no item is selected.
+// Without it, the item marked with `default: true` is selected.
+func DefaultItem(id string) func(*BindCollectionOptions) {
+	return func(o *BindCollectionOptions) {
+		o.defaultItem = id
+	}
+}
+
+// defaultItemId returns the id of the default collection item or an empty string
+func (o *BindCollectionOptions) defaultItemId(coll []map[string]interface{}) string {
+	if o.defaultItem != "" {
+		return o.defaultItem
+	}
+	for i := 0; i < len(coll); i++ {
+		if isDefault, ok := coll[i]["default"].(bool); ok && isDefault {
+			return fmt.Sprintf("%v", coll[i][o.idField])
+		}
+	}
+	return ""
+}
+
+// Within reads the collection from the item selected in a parent collection.
+// The parent collection is configured with the same options and can be nested further.
+func Within(options ...func(*BindCollectionOptions)) func(*BindCollectionOptions) {
+	return func(o *BindCollectionOptions) {
+		parent := newBindCollectionOptions(options)
+		o.parent = &parent
+	}
+}
+
+// items returns the collection from the Collection option, the parent item or the config
+func (o *BindCollectionOptions) items() ([]map[string]interface{}, error) {
+	var coll []map[string]interface{}
+	if o.collection != nil {
+		coll = *o.collection
+	}
+	if coll != nil {
+		return coll, nil
+	}
+	if o.parent == nil {
+		return loadCollection(o.collectionField)
+	}
+	item, err := o.parent.selectedItem()
+	if err != nil || item == nil {
+		return nil, err
+	}
+	for k, v := range item {
+		if strings.EqualFold(k, o.collectionField) {
+			err = mapstructure.Decode(v, &coll)
+			break
+		}
+	}
+	return coll, err
+}
+
+// findItem returns the index of the item matching the value or -1.
+// Exact matches in collections from the config are looked up in the cached index.
+func (o *BindCollectionOptions) findItem(coll []map[string]interface{}, value string) int {
+	exact := o.match == nil && !o.ignoreCase && !o.prefix
+	if exact && o.parent == nil && (o.collection == nil || *o.collection == nil) {
+		if c, err := cachedCollection(o.collectionField); err == nil {
+			return c.lookup(o.idField, value)
+		}
+	}
+	return findCollectionItem(coll, o.idField, value, o.matches)
+}
+
+// selectedItem returns the selected item of a parent collection.
+// Like in flat collections, a missing item is only an error with RequireItem.
+func (o *BindCollectionOptions) selectedItem() (map[string]interface{}, error) {
+	coll, err := o.items()
+	if err != nil {
+		return nil, err
+	}
+	value := o.selectedValue()
+	if value == "" {
+		value = o.defaultItemId(coll)
+	}
+	if i := o.findItem(coll, value); i >= 0 {
+		return coll[i], nil
+	}
+	if !o.requireItem {
+		return nil, nil
+	}
+	return nil, o.itemNotFound(value, o.collectionIds())
+}
+
+// ItemCommands adds a subcommand for each collection item, bound to the values of that item.
+// The subcommands run the Run function of the bound command.
+func ItemCommands(o *BindCollectionOptions) { o.itemCommands = true }
+
+// createItemCommands adds the item subcommands to the command
+func (o *BindCollectionOptions) createItemCommands(c *cobra.Command, rawVal interface{}, options []func(*BindCollectionOptions)) {
+	if !o.itemCommands {
+		return
+	}
+	o.itemCmds = make(map[*cobra.Command]bool)
+	for _, id := range o.collectionIds() {
+		sub := &cobra.Command{
+			Use:   id,
+			Short: fmt.Sprintf("Run %s with %s", c.Name(), id),
+			Args:  c.Args,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if c.RunE != nil {
+					return c.RunE(cmd, args)
+				}
+				if c.Run != nil {
+					c.Run(cmd, args)
+				}
+				return nil
+			},
+		}
+		c.AddCommand(sub)
+		o.itemCmds[sub] = true
+		BindCollectionItem(sub, rawVal, append(options, SelectValue(id), func(o *BindCollectionOptions) {
+			o.itemCommands = false
+			o.selectorFlag = false
+		})...)
+	}
+}
+
+// MultiSelect selects several comma separated collection items and merges them in order
+func MultiSelect(o *BindCollectionOptions) { o.multiple = true }
+
+// selectedValues splits the selected value when selecting multiple items
+func (o *BindCollectionOptions) selectedValues(value string) []string {
+	if !o.multiple {
+		return []string{value}
+	}
+	values := strings.Split(value, ",")
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+	return values
+}
+
+// SelectorFlag generates a flag for the select field on the bound command itself
+func SelectorFlag(o *BindCollectionOptions) { o.selectorFlag = true }
+
+// createSelectorFlag adds the selector flag and completes it with the collection item ids
+func (o *BindCollectionOptions) createSelectorFlag(c *cobra.Command) {
+	if o.parent != nil {
+		o.parent.createSelectorFlag(c)
+	}
+	flagName := FlagNaming(o.selectField)
+	if o.selectorFlag {
+		c.PersistentFlags().StringVar(&o.selector, flagName, "", fmt.Sprintf("Select the %s item", o.collectionField))
+	}
+	if c.Flag(flagName) != nil {
+		c.RegisterFlagCompletionFunc(flagName, o.completeItems)
+	}
+}
+
+// completeItems completes the ids of the collection items
+func (o *BindCollectionOptions) completeItems(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return o.collectionIds(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// RegisterItemCompletion completes the flag with the ids of the collection items.
+// Selector flags that exist when binding a collection are completed automatically.
+func RegisterItemCompletion(c *cobra.Command, flagName string, options ...func(*BindCollectionOptions)) error {
+	opts := newBindCollectionOptions(options)
+	return c.RegisterFlagCompletionFunc(flagName, opts.completeItems)
+}
+
+// selectedValue returns the SelectValue, the value of the selector flag or the select field
+func (o *BindCollectionOptions) selectedValue() string {
+	if o.selectValue != "" {
+		return o.selectValue
+	}
+	if o.selector != "" {
+		return o.selector
+	}
+	return GetString(o.selectField)
+}
+
+// collectionIds returns the id field values of the collection
+func (o *BindCollectionOptions) collectionIds() []string {
+	coll, _ := o.items()
+	var ids []string
+	for i := 0; i < len(coll); i++ {
+		if val, ok := coll[i][o.idField]; ok {
+			ids = append(ids, fmt.Sprintf("%v", val))
+		}
+	}
+	return ids
+}
+
+// RequireItem fails the command when no collection item matches the selected value
+func RequireItem(o *BindCollectionOptions) { o.requireItem = true }
+
+// WarnMissingItem prints a warning when no collection item matches the selected value
+func WarnMissingItem(o *BindCollectionOptions) { o.warnMissing = true }
+
+// missingItem returns the error for an unmatched selection, or prints it as a warning
+func (o *BindCollectionOptions) missingItem(cmd *cobra.Command, value string, ids []string) error {
+	if !o.requireItem && !o.warnMissing {
+		return nil
+	}
+	err := o.itemNotFound(value, ids)
+	if o.requireItem {
+		return err
+	}
+	fmt.Fprintln(cmd.ErrOrStderr(), "Warning:", err)
+	return nil
+}
+
+// itemNotFound returns a descriptive error listing the available ids
+func (o *BindCollectionOptions) itemNotFound(value string, ids []string) error {
+	name := o.collectionField
+	if name == "" {
+		name = "collection"
+	}
+	if ids == nil {
+		ids = []string{}
+	}
+	return &ErrItemNotFound{Collection: name, ID: value, Available: ids}
+}
+
+// matches reports whether the id matches the selected value using the configured matching
+func (o *BindCollectionOptions) matches(id interface{}, value string) bool {
+	if o.match != nil {
+		return o.match(id, value)
+	}
+	s := fmt.Sprintf("%v", id)
+	if o.ignoreCase {
+		s, value = strings.ToLower(s), strings.ToLower(value)
+	}
+	if o.prefix {
+		return strings.HasPrefix(s, value)
+	}
+	return s == value
+}
+
+// newBindCollectionOptions applies the options over the defaults
+func newBindCollectionOptions(options []func(*BindCollectionOptions)) BindCollectionOptions {
+	opts := BindCollectionOptions{
+		idField: "name",
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	return opts
 }
 
 func IdField(name string) func(*BindCollectionOptions) {
@@ -204,119 +985,189 @@ func BindCollectionItemFields(colField string, selectField string, c *cobra.Comm
 }
 
 func BindCollectionItem(c *cobra.Command, rawVal interface{}, options ...func(*BindCollectionOptions)) {
-	var opts BindCollectionOptions
-	for _, option := range options {
-		option(&opts)
-	}
-	var idField = opts.idField
-	if idField == "" {
-		idField = "name"
-	}
-	var selectField = opts.selectField
-	var collField = opts.collectionField
-	createFlags(c.PersistentFlags(), rawVal)
+	opts := newBindCollectionOptions(options)
+	registerCollection(&opts, reflect.TypeOf(rawVal).Elem(), true)
+	createFlags(c.PersistentFlags(), rawVal, FlagNaming)
+	c.Flags().SortFlags = SortFlags
+	opts.createSelectorFlag(c)
+	opts.createItemCommands(c, rawVal, options)
 	cobrahooks.OnPersistentPreRun(c, func(cmd *cobra.Command, args []string) error {
 		fmt.Println("RUN PersistentFlagsCollection:", c.Use)
-		selectValue := GetString(selectField)
-		var coll []map[string]interface{}
-		if opts.collection != nil {
-			coll = *opts.collection
-		}
-		if coll == nil {
-			fmt.Println("UNMARSHALL COLLECTION:", c.Use)
-			UnmarshalKey(collField, &coll)
-		}
-		for i := 0; i < len(coll); i++ {
-			if val, ok := coll[i][idField]; ok {
-				if val.(string) == selectValue {
-					curVal := getPtrValue(rawVal)
-					if err := mapstructure.Decode(coll[i], rawVal); err != nil {
-						return err
-					}
-					if err := mergo.MergeWithOverwrite(rawVal, curVal); err != nil {
-						return err
-					}
-					setFlagDefaults(c.PersistentFlags(), rawVal)
-					return nil
+		if opts.itemCmds[cmd] {
+			// The item subcommand binds its own item
+			return nil
+		}
+		selectValue := opts.selectedValue()
+		coll, err := opts.items()
+		if err != nil {
+			return err
+		}
+		if selectValue == "" {
+			selectValue = opts.defaultItemId(coll)
+		}
+		var selected map[string]interface{}
+		for _, value := range opts.selectedValues(selectValue) {
+			i := opts.findItem(coll, value)
+			if i < 0 {
+				if err := opts.missingItem(cmd, value, opts.collectionIds()); err != nil {
+					return err
 				}
+				continue
 			}
+			selected = mergeMaps(selected, coll[i])
+		}
+		if selected == nil {
+			return nil
 		}
+		curVal := getPtrValue(rawVal)
+		if err := mapstructure.Decode(selected, rawVal); err != nil {
+			return err
+		}
+		if err := mergo.MergeWithOverwrite(rawVal, curVal); err != nil {
+			return err
+		}
+		setFlagDefaults(c.PersistentFlags(), rawVal, FlagNaming)
 		return nil
 	}, cobrahooks.RunOnHelp)
 }
 
-// setFlagDefaults takes the values of a Struct and sets them as flag defaults
-func setFlagDefaults(flags *pflag.FlagSet, rawVal interface{}) {
-	rvp := reflect.ValueOf(rawVal) // pointer struct value
-	rtp := reflect.TypeOf(rawVal)  // pointer struct type
-	if k := rvp.Kind(); k != reflect.Ptr {
-		panic("Value is not a pointer")
+// mergeMaps returns a copy of dst with src deep-merged into it
+func mergeMaps(dst map[string]interface{}, src map[string]interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(dst)+len(src))
+	for k, v := range dst {
+		m[k] = v
 	}
-	rv := rvp.Elem() // struct value from pointer
-	rt := rtp.Elem() // struct type from pointer
-	if k := rv.Kind(); k != reflect.Struct {
-		panic("Value is not a struct")
+	for k, v := range src {
+		if v == Unset {
+			delete(m, k)
+			continue
+		}
+		srcMap, srcOk := toStringMap(v)
+		dstMap, dstOk := toStringMap(m[k])
+		if srcOk && dstOk {
+			m[k] = mergeMaps(dstMap, srcMap)
+			continue
+		}
+		m[k] = v
+	}
+	return m
+}
+
+// toStringMap converts the nested maps returned by the config decoders
+func toStringMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		sm := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			sm[fmt.Sprintf("%v", k)] = v
+		}
+		return sm, true
 	}
-	for i := 0; i < rv.NumField(); i++ {
-		fv := rv.Field(i) // value
-		ft := rt.Field(i) // struct field type
-		flag := flags.Lookup(strcase.ToKebab(ft.Name))
-		if flag != nil {
-			flag.DefValue = fmt.Sprintf("%v", fv.Interface())
+	return nil, false
+}
+
+// setFlagDefaults takes the values of a Struct and sets them as flag defaults
+func setFlagDefaults(flags *pflag.FlagSet, rawVal interface{}, flagName NamingFunc) {
+	rv := structValue(rawVal)
+	for _, f := range structFields(rv.Type()) {
+		flag := flags.Lookup(flagName(f.name))
+		if flag == nil {
+			continue
+		}
+		fv := rv.Field(f.index)
+		if values, ok := fv.Interface().([]string); ok {
+			flag.DefValue = "[" + strings.Join(values, ",") + "]"
+			continue
 		}
+		flag.DefValue = fmt.Sprintf("%v", fv.Interface())
 	}
 }
 
-// Generates cobra flags based on a Struct
-func createFlags(flags *pflag.FlagSet, rawVal interface{}) {
+// CreateFlags generates flags on the FlagSet based on a Struct
+func CreateFlags(flags *pflag.FlagSet, rawVal interface{}, options ...func(*BindOptions)) {
+	opts := newBindOptions(rawVal, options)
+	createFlags(flags, rawVal, opts.flagName)
+}
+
+func createFlags(flags *pflag.FlagSet, rawVal interface{}, flagName NamingFunc) {
 	// https://blog.golang.org/laws-of-reflection
-	rvp := reflect.ValueOf(rawVal) // pointer struct value
-	rtp := reflect.TypeOf(rawVal)  // pointer struct type
-	if k := rvp.Kind(); k != reflect.Ptr {
-		panic("Value is not a pointer")
-	}
-	rv := rvp.Elem() // struct value from pointer
-	rt := rtp.Elem() // struct type from pointer
-	if k := rv.Kind(); k != reflect.Struct {
-		panic("Value is not a struct")
-	}
-	for i := 0; i < rv.NumField(); i++ {
-		fv := rv.Field(i) // value
-		ft := rt.Field(i) // struct field type
-		flagName := strcase.ToKebab(ft.Name)
-		switch fv.Kind() {
+	rv := structValue(rawVal)
+	flags.SortFlags = SortFlags
+	for _, f := range structFields(rv.Type()) {
+		if f.argIndex >= 0 {
+			continue
+		}
+		fv := rv.Field(f.index)
+		name := flagName(f.name)
+		if value := flagValue(fv, f); value != nil {
+			flags.VarP(value, name, "", f.usage)
+			flagFields.Store(flags.Lookup(name), f)
+			continue
+		}
+		switch f.kind {
 		case reflect.Bool:
 			flags.BoolVarP(
 				fv.Addr().Interface().(*bool),
-				flagName, "",
+				name, "",
 				fv.Interface().(bool),
-				ft.Tag.Get("usage"))
+				f.usage)
 			break
 		case reflect.String:
 			flags.StringVarP(
 				fv.Addr().Interface().(*string),
-				flagName, "",
+				name, "",
 				fv.Interface().(string),
-				ft.Tag.Get("usage"))
+				f.usage)
 			break
 		case reflect.Float64:
 			flags.Float64VarP(
 				fv.Addr().Interface().(*float64),
-				flagName, "",
+				name, "",
 				fv.Interface().(float64),
-				ft.Tag.Get("usage"))
+				f.usage)
 			break
 		case reflect.Int:
 			flags.IntVarP(
 				fv.Addr().Interface().(*int),
-				flagName, "",
+				name, "",
 				fv.Interface().(int),
-				ft.Tag.Get("usage"))
+				f.usage)
+			break
+		case reflect.Slice:
+			if f.typ.Elem().Kind() != reflect.String {
+				break
+			}
+			flags.StringSliceVarP(
+				fv.Addr().Interface().(*[]string),
+				name, "",
+				fv.Interface().([]string),
+				f.usage)
 			break
 		}
+		if flag := flags.Lookup(name); flag != nil {
+			flagFields.Store(flag, f)
+		}
 	}
 }
 
+// flagValue returns the flag value of fields with a type that has its own parser, like durations and percentages
+func flagValue(fv reflect.Value, f fieldInfo) pflag.Value {
+	switch f.typ {
+	case durationType:
+		return (*durationValue)(fv.Addr().Interface().(*time.Duration))
+	case percentType:
+		return fv.Addr().Interface().(*Percent)
+	case secretType:
+		return fv.Addr().Interface().(*Secret)
+	}
+	return nil
+}
+
+// ConfigLoader reads the config into viper on first access. It can Get and Set values:
+// config calls don't wait for the load while it runs, so other goroutines reading the config
+// at the same time can see it partly loaded.
 var ConfigLoader = func() {
 	// Find home directory.
 	home, err := homedir.Dir()
@@ -348,7 +1199,7 @@ var ConfigLoader = func() {
 	viper.AddConfigPath(curDir)
 	viper.SetConfigName("." + name) // .video.yaml
 	// viper.SetConfigName(name)
-	viper.AutomaticEnv() // read in environment variables that match
+	AutomaticEnv() // read in environment variables that match
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
@@ -356,17 +1207,129 @@ var ConfigLoader = func() {
 	}
 }
 
-var once sync.Once
+var (
+	once   sync.Once
+	loaded bool
+	// loading is 1 while runConfigLoader runs the ConfigLoader, so the config calls made meanwhile
+	// don't wait for the load
+	loading int32
+	// mu guards viper's global state, so config can be read while a reload is applied
+	mu sync.RWMutex
+)
 
 // initConfig reads in config file and ENV variables if set.
 func loadConfig() {
-	once.Do(ConfigLoader)
+	if atomic.LoadInt32(&loading) == 1 {
+		return
+	}
+	once.Do(func() {
+		start := time.Now()
+		end := startSpan("cfg.load")
+		importEnvFiles()
+		runConfigLoader()
+		mu.Lock()
+		settings, err := loaderConfig()
+		if err != nil {
+			mu.Unlock()
+			panic(err)
+		}
+		settings, migrated, err := upgradeSettings(settings, viper.ConfigFileUsed())
+		if err != nil {
+			fmt.Fprintf(WarningOutput, "Warning: %s\n", err)
+		}
+		layers.file = settings
+		rebuildConfig()
+		stampConfig()
+		mu.Unlock()
+		end(nil)
+		recordLoad(start, false, nil)
+		loaded = true
+		writeMigrations(migrated)
+		resolveExecValues()
+	})
+}
+
+// loaderConfig returns the settings the ConfigLoader read. With a verify key, the config file
+// is read again and verified, so the settings are the verified bytes. A file with !exec tags
+// is read again as well, so the tagged values keep their !exec prefix. The caller must hold the write lock.
+func loaderConfig() (map[string]interface{}, error) {
+	file := viper.ConfigFileUsed()
+	if file == "" || verifyKey.key == nil && !hasExecTags(file) {
+		return viperConfig(), nil
+	}
+	if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) {
+		return viperConfig(), nil
+	}
+	return readConfigFile(file)
+}
+
+// runConfigLoader runs the ConfigLoader without holding the lock, so it can Get and Set values
+func runConfigLoader() {
+	atomic.StoreInt32(&loading, 1)
+	defer atomic.StoreInt32(&loading, 0)
+	ConfigLoader()
 }
 
+// Reset clears the loaded config, the values that were Set, the defaults, the flags bound with BindPFlags,
+// the environment settings, the caches, the traced keys, the bound Structs and collections
+// and the registered aliases and migrations, so the next access runs the ConfigLoader again.
+// Templates and secret resolvers stay registered.
+// Reset must not run while another goroutine loads or reloads the config, like in parallel tests.
+func Reset() {
+	mu.Lock()
+	viper.Reset()
+	defaultValues = make(map[string]interface{})
+	layers.file, layers.fragments, layers.set, layers.cleared, layers.credentials = nil, nil, nil, nil, nil
+	overrideStack = nil
+	execRefs = nil
+	boundFlags = nil
+	resetEnv()
+	once = sync.Once{}
+	loaded = false
+	mu.Unlock()
+	resetSchema()
+	aliases.Lock()
+	aliases.list = nil
+	aliases.Unlock()
+	migrations.Lock()
+	migrations.list = nil
+	migrations.Unlock()
+	resetCache()
+	resetTrace()
+}
+
+// Write writes the settings of the config file with the values that were Set to the config file.
+// Values from the environment, flags and defaults, merged fragments, credentials and pushed overrides are not written.
+// The config file is locked while it is written and read again when another process changed it.
+// Signed config files are not written, see SetVerifyKey.
 func Write() error {
-	if err := viper.WriteConfig(); err != nil {
+	loadConfig()
+	mu.RLock()
+	file := viper.ConfigFileUsed()
+	mu.RUnlock()
+	unlock, err := lockConfig(file)
+	if err != nil {
 		return err
 	}
-	fmt.Println("Writing config:", viper.ConfigFileUsed())
-	return nil
+	defer unlock()
+	mu.Lock()
+	defer resetCache()
+	defer mu.Unlock()
+	if err := checkUnsigned(file); err != nil {
+		return err
+	}
+	settings := deepCopy(layers.file).(map[string]interface{})
+	if configChanged(file) {
+		// keep the edits another process wrote since the config was read
+		if settings, err = readConfigFile(file); err != nil {
+			return err
+		}
+	}
+	settings = overlay(settings, deepCopy(layers.set).(map[string]interface{}))
+	if err := writeAtomic(file, settings); err != nil {
+		return configError(err)
+	}
+	stampConfig()
+	layers.file = settings
+	return rebuildConfig()
 }
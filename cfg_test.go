@@ -3,9 +3,17 @@ package cfg
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -30,6 +38,8 @@ collection:
    name: ThirdItem
 ninthParam: Ninth
 tenthParam: 9
+pflagSection:
+   ThirteenthParam: Thirteenth
 `)
 
 func executeCommandC(root *cobra.Command, args ...string) (c *cobra.Command, output string, err error) {
@@ -46,6 +56,22 @@ func executeCommand(root *cobra.Command, args ...string) (output string, err err
 	return output, err
 }
 
+// useConfigFile writes the content to a temporary config file and loads it until the test finishes
+func useConfigFile(t *testing.T, content string) string {
+	Reset()
+	t.Cleanup(Reset)
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ReadInConfig()
+	viper.SetConfigFile(file)
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
 func init() {
 	ConfigLoader = func() {
 		fmt.Println("Test Reading config")
@@ -228,3 +254,598 @@ func TestRunBoundCollectionCommand(t *testing.T) {
 		t.Errorf("\ngot:  %v\nwant: %v\n", itemConfig, itemTest)
 	}
 }
+
+func TestResolveFlagSet(t *testing.T) {
+
+	var config child2Struct
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	CreateFlags(flags, &config)
+
+	if err := flags.Parse([]string{"--sixth-param", "SixthFlag"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if err := Resolve(flags, &config, Key("nested")); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	want := child2Struct{
+		FourthParam: true,
+		FifthParam:  78,
+		SixthParam:  "SixthFlag",
+	}
+
+	if config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+
+	if def := flags.Lookup("fifth-param").DefValue; def != "78" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", def, "78")
+	}
+}
+
+type pflagStruct struct {
+	TwelfthParam    string
+	ThirteenthParam string
+}
+
+func TestRunBoundCommandBindPFlags(t *testing.T) {
+
+	var config pflagStruct
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindPersistentFlags(rootCmd, &config, Key("pflagSection"), BindPFlags)
+
+	if _, err := executeCommand(rootCmd, "--twelfth-param", "TwelfthFlag"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if got := viper.GetString("pflagSection.TwelfthParam"); got != "TwelfthFlag" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "TwelfthFlag")
+	}
+
+	if got := viper.GetString("pflagSection.ThirteenthParam"); got != "Thirteenth" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "Thirteenth")
+	}
+
+	if want := (pflagStruct{TwelfthParam: "TwelfthFlag", ThirteenthParam: "Thirteenth"}); config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+}
+
+type saveStruct struct {
+	Region string
+	Zone   string
+}
+
+func TestRunBoundCommandSaveFlag(t *testing.T) {
+
+	Reset()
+	t.Cleanup(Reset)
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	viper.SetConfigFile(filepath.Join(dir, "config.yaml"))
+
+	var config saveStruct
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindPersistentFlags(rootCmd, &config, Key("saveSection"), SaveFlag)
+
+	if _, err := executeCommand(rootCmd, "--region", "eu-west-1", "--save"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	written := viper.New()
+	written.SetConfigFile(filepath.Join(dir, "config.yaml"))
+	if err := written.ReadInConfig(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if got := written.GetString("saveSection.region"); got != "eu-west-1" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "eu-west-1")
+	}
+
+	if written.IsSet("saveSection.zone") {
+		t.Errorf("Unexpected zone: %v", written.Get("saveSection.zone"))
+	}
+}
+
+func TestRunBoundCommandScopeByCommand(t *testing.T) {
+
+	var (
+		rootConfig  child2Struct
+		childConfig child2Struct
+	)
+
+	Set("scoped.nested", map[string]interface{}{
+		"FourthParam": true,
+		"FifthParam":  5,
+		"SixthParam":  "ScopedSixth",
+	})
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	scopedCmd := &cobra.Command{
+		Use: "scoped",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	nestedCmd := &cobra.Command{
+		Use: "nested",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	rootCmd.AddCommand(scopedCmd)
+	scopedCmd.AddCommand(nestedCmd)
+
+	BindFlags(rootCmd, &rootConfig, Key("nested"), ScopeByCommand)
+	BindFlags(nestedCmd, &childConfig, ScopeByCommand)
+
+	if _, err := executeCommand(rootCmd); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if _, err := executeCommand(rootCmd, "scoped", "nested", "--fifth-param", "6"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if want := (child2Struct{FourthParam: true, FifthParam: 78, SixthParam: "Sixth"}); rootConfig != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", rootConfig, want)
+	}
+
+	if want := (child2Struct{FourthParam: true, FifthParam: 6, SixthParam: "ScopedSixth"}); childConfig != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", childConfig, want)
+	}
+}
+
+type NestedConfig struct {
+	FourthParam bool
+	FifthParam  int
+	SixthParam  string
+}
+
+func TestRunBoundCommandKeyFromType(t *testing.T) {
+
+	var config NestedConfig
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, KeyFromType)
+
+	if _, err := executeCommand(rootCmd); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if want := (NestedConfig{FourthParam: true, FifthParam: 78, SixthParam: "Sixth"}); config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+
+	if key := typeKey(&rootStruct{}); key != "rootStruct" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", key, "rootStruct")
+	}
+}
+
+func TestRunBoundCommandFlagNames(t *testing.T) {
+
+	var (
+		snakeConfig  child2Struct
+		customConfig rootStruct2
+	)
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &snakeConfig, Key("nested"), FlagNames(SnakeCase))
+	BindFlags(rootCmd, &customConfig, FlagNames(func(name string) string {
+		return "x-" + strings.ToLower(name)
+	}))
+
+	if _, err := executeCommand(rootCmd, "--fifth_param", "102", "--x-ninthparam", "NinthFlag"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if want := (child2Struct{FourthParam: true, FifthParam: 102, SixthParam: "Sixth"}); snakeConfig != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", snakeConfig, want)
+	}
+
+	if want := (rootStruct2{NinthParam: "NinthFlag", TenthParam: 9}); customConfig != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", customConfig, want)
+	}
+
+	if def := rootCmd.Flags().Lookup("sixth_param").DefValue; def != "Sixth" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", def, "Sixth")
+	}
+}
+
+type snakeStruct struct {
+	FirstValue  string
+	SecondValue int
+	ThirdValue  string
+}
+
+func TestRunBoundCommandKeyNames(t *testing.T) {
+
+	var config snakeStruct
+
+	Set("snakeSection", map[string]interface{}{
+		"first_value":  "First",
+		"second_value": 2,
+		"legacy":       "Third",
+	})
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, Key("snakeSection"), KeyNames(SnakeCase), KeyMap(map[string]string{
+		"ThirdValue": "legacy",
+	}))
+
+	if _, err := executeCommand(rootCmd, "--second-value", "3"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if want := (snakeStruct{FirstValue: "First", SecondValue: 3, ThirdValue: "Third"}); config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+}
+
+type strictStruct struct {
+	Port    int
+	Enabled bool
+}
+
+func TestResolveStrictTypes(t *testing.T) {
+
+	Set("strictSection", map[string]interface{}{
+		"port":    "8080x",
+		"enabled": "1",
+	})
+
+	var config strictStruct
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	CreateFlags(flags, &config)
+
+	err := Resolve(flags, &config, Key("strictSection"), StrictTypes)
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	for _, key := range []string{"Port", "Enabled"} {
+		if !strings.Contains(err.Error(), "'"+key+"'") {
+			t.Errorf("Expected an error for %s: %v", key, err)
+		}
+	}
+
+	Set("strictSection", map[string]interface{}{
+		"port":    8080,
+		"enabled": true,
+	})
+
+	if err := Resolve(flags, &config, Key("strictSection"), StrictTypes); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if want := (strictStruct{Port: 8080, Enabled: true}); config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+}
+
+type zeroStruct struct {
+	Enabled bool
+	Retries int
+	Name    string
+}
+
+func TestResolveExplicitZeroValues(t *testing.T) {
+
+	Set("zeroSection", map[string]interface{}{
+		"enabled": false,
+		"retries": 0,
+	})
+
+	config := zeroStruct{Enabled: true, Retries: 3, Name: "default"}
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	CreateFlags(flags, &config)
+
+	if err := Resolve(flags, &config, Key("zeroSection")); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if want := (zeroStruct{Enabled: false, Retries: 0, Name: "default"}); config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+}
+
+func TestRunBoundCommandChangedFlags(t *testing.T) {
+
+	Set("changedSection", map[string]interface{}{
+		"enabled": true,
+		"retries": 5,
+		"name":    "First",
+	})
+
+	config := zeroStruct{Retries: 3}
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, Key("changedSection"))
+
+	if _, err := executeCommand(rootCmd, "--enabled=false", "--retries", "0"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if want := (zeroStruct{Enabled: false, Retries: 0, Name: "First"}); config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+
+	Set("changedSection", map[string]interface{}{
+		"enabled": true,
+	})
+
+	if _, err := executeCommand(rootCmd, "--enabled=false"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Flags keep their Changed state between executions
+	if want := (zeroStruct{Enabled: false, Retries: 0, Name: ""}); config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			Set("concurrent.value", fmt.Sprintf("value%d", i))
+		}(i)
+		go func() {
+			defer wg.Done()
+			_ = GetString("concurrent.value")
+			_ = cachedSettings("concurrent")
+		}()
+	}
+	wg.Wait()
+
+	if got := GetString("concurrent.value"); got == "" {
+		t.Errorf("Expected a value")
+	}
+}
+
+func TestReset(t *testing.T) {
+
+	defer func(loader func()) {
+		ConfigLoader = loader
+		Reset()
+	}(ConfigLoader)
+
+	Set("resetParam", "Set")
+
+	ConfigLoader = func() {
+		viper.SetConfigType("yaml")
+		if err := viper.ReadConfig(bytes.NewBufferString("otherParam: Other\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	Reset()
+
+	if got := GetString("otherParam"); got != "Other" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "Other")
+	}
+
+	if got := GetString("resetParam"); got != "" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "")
+	}
+}
+
+func TestResetRegistries(t *testing.T) {
+
+	Reset()
+	t.Cleanup(Reset)
+
+	type ResetConfig struct {
+		Secret string `secret:"true"`
+	}
+	BindFlagsKey("resetStruct", &cobra.Command{}, &ResetConfig{})
+	RegisterAlias("resetOld", "resetNew")
+	RegisterMigration(0, 1, func(settings map[string]interface{}) error { return nil })
+	Reset()
+
+	schemaKeys.Lock()
+	got := len(schemaKeys.keys) + len(schemaKeys.secrets) + len(schemaKeys.bindings) + len(schemaKeys.collections)
+	schemaKeys.Unlock()
+	aliases.Lock()
+	got += len(aliases.list)
+	aliases.Unlock()
+	migrations.Lock()
+	got += len(migrations.list)
+	migrations.Unlock()
+	if got != 0 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, 0)
+	}
+}
+
+func TestConfigLoaderAccess(t *testing.T) {
+
+	defer func(loader func()) {
+		ConfigLoader = loader
+		Reset()
+	}(ConfigLoader)
+
+	ConfigLoader = func() {
+		viper.SetConfigType("yaml")
+		if err := viper.ReadConfig(bytes.NewBufferString("loaderParam: Read\n")); err != nil {
+			t.Fatal(err)
+		}
+		Set("loaderSet", GetString("loaderParam")+" and Set")
+		// config calls from helper goroutines don't wait for the load either
+		helper := make(chan string)
+		go func() { helper <- GetString("loaderParam") }()
+		Set("loaderHelper", <-helper)
+	}
+	Reset()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if got := GetString("loaderSet"); got != "Read and Set" {
+			t.Errorf("\ngot:  %v\nwant: %v\n", got, "Read and Set")
+		}
+		if got := GetString("loaderHelper"); got != "Read" {
+			t.Errorf("\ngot:  %v\nwant: %v\n", got, "Read")
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ConfigLoader deadlocked")
+	}
+}
+
+type orderedStruct struct {
+	Zulu  string
+	Alpha string
+	Mike  int
+}
+
+type secondOrderedStruct struct {
+	Bravo string
+}
+
+func TestFlagOrder(t *testing.T) {
+
+	var (
+		config orderedStruct
+		second secondOrderedStruct
+	)
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, NoViper)
+	BindFlags(rootCmd, &second, NoViper)
+
+	var names []string
+	rootCmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		names = append(names, f.Name)
+	})
+
+	if want := []string{"zulu", "alpha", "mike", "bravo"}; strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("\ngot:  %v\nwant: %v\n", names, want)
+	}
+}
+
+func TestGetOr(t *testing.T) {
+
+	Set("getOrSection", map[string]interface{}{
+		"port":    "8080",
+		"enabled": false,
+		"retries": 0,
+		"name":    "",
+	})
+
+	if got := GetOr("getOrSection.port", 80); got != 8080 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, 8080)
+	}
+
+	if got := GetOr("getOrSection.enabled", true); got != false {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, false)
+	}
+
+	if got := GetIntOr("getOrSection.retries", 3); got != 0 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, 0)
+	}
+
+	if got := GetStringOr("getOrSection.name", "default"); got != "" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "")
+	}
+
+	if got := GetStringOr("getOrSection.missing", "default"); got != "default" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "default")
+	}
+
+	if got := GetOr("getOrSection.port", []string{"fallback"}); len(got) != 1 || got[0] != "8080" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, []string{"8080"})
+	}
+}
+
+func TestGetAllWithPrefix(t *testing.T) {
+
+	Set("prefixFeature", map[string]interface{}{
+		"search": true,
+		"export": map[string]interface{}{"format": "csv"},
+	})
+	Set("prefixFeatureOther", "excluded")
+
+	want := map[string]interface{}{
+		"prefixfeature.search":        true,
+		"prefixfeature.export.format": "csv",
+	}
+
+	if got := GetAllWithPrefix("prefixFeature."); !reflect.DeepEqual(got, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, want)
+	}
+}
+
+func TestUnmarshalKeyMap(t *testing.T) {
+
+	Set("mapLabels", map[string]interface{}{"team": "core", "tier": "backend"})
+	Set("mapPort", 8080)
+
+	labels := map[string]string{"tier": "frontend"}
+	if err := UnmarshalKey("mapLabels", &labels); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if want := map[string]string{"team": "core", "tier": "frontend"}; !reflect.DeepEqual(labels, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", labels, want)
+	}
+
+	var empty map[string]string
+	if err := UnmarshalKey("mapLabels", &empty); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if want := map[string]string{"team": "core", "tier": "backend"}; !reflect.DeepEqual(empty, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", empty, want)
+	}
+
+	var port int
+	if err := UnmarshalKey("mapPort", &port); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if port != 8080 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", port, 8080)
+	}
+}
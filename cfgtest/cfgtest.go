@@ -0,0 +1,88 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cfgtest provides helpers for testing commands that use cfg.
+// The helpers replace the config for the duration of a test and restore it afterwards.
+package cfgtest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bartdeboer/cfg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// WithConfigYAML uses the YAML as the config for the duration of the test
+func WithConfigYAML(t testing.TB, yaml string) {
+	t.Helper()
+	withLoader(t, func() {
+		viper.SetConfigType("yaml")
+		if err := viper.ReadConfig(strings.NewReader(yaml)); err != nil {
+			t.Fatalf("reading config: %v", err)
+		}
+	})
+}
+
+// WithTempConfigFile writes the content to a temporary config file with the format as extension,
+// like "yaml" or "json", and uses it as the config for the duration of the test.
+// Returns the path of the file, which is also where cfg.Write writes to.
+func WithTempConfigFile(t testing.TB, format string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config."+format)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+	withLoader(t, func() {
+		viper.SetConfigFile(path)
+		if err := viper.ReadInConfig(); err != nil {
+			t.Fatalf("reading config: %v", err)
+		}
+	})
+	return path
+}
+
+// WithEnv sets the environment variables for the duration of the test
+// and reads them as config values on top of the current config
+func WithEnv(t testing.TB, env map[string]string) {
+	t.Helper()
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+	loader := cfg.ConfigLoader
+	withLoader(t, func() {
+		loader()
+		cfg.AutomaticEnv()
+	})
+}
+
+// withLoader replaces the ConfigLoader and reloads the config until the test finishes
+func withLoader(t testing.TB, loader func()) {
+	prev := cfg.ConfigLoader
+	cfg.ConfigLoader = loader
+	cfg.Reset()
+	cfg.ReadInConfig()
+	t.Cleanup(func() {
+		cfg.ConfigLoader = prev
+		cfg.Reset()
+	})
+}
+
+// ExecuteBound runs the command with the arguments and returns its output.
+// The test fails when the command returns an error.
+func ExecuteBound(t testing.TB, cmd *cobra.Command, args ...string) string {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetErr(buf)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("executing %s %s: %v\n%s", cmd.Name(), strings.Join(args, " "), err, buf.String())
+	}
+	return buf.String()
+}
@@ -0,0 +1,64 @@
+package cfgtest
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bartdeboer/cfg"
+	"github.com/spf13/cobra"
+)
+
+type serverConfig struct {
+	Host string
+	Port int
+}
+
+func TestWithConfigYAML(t *testing.T) {
+
+	WithConfigYAML(t, "server:\n  host: example.com\n  port: 8080\n")
+
+	var config serverConfig
+
+	cmd := &cobra.Command{
+		Use: "serve",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	cfg.BindFlags(cmd, &config, cfg.Key("server"))
+
+	ExecuteBound(t, cmd, "--port", "9090")
+
+	if want := (serverConfig{Host: "example.com", Port: 9090}); config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+}
+
+func TestWithTempConfigFile(t *testing.T) {
+
+	path := WithTempConfigFile(t, "yaml", "name: First\n")
+
+	cfg.Set("name", "Second")
+	if err := cfg.Write(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(b), "name: Second") {
+		t.Errorf("Unexpected config: %q", b)
+	}
+}
+
+func TestWithEnv(t *testing.T) {
+
+	WithConfigYAML(t, "region: eu-west-1\n")
+	WithEnv(t, map[string]string{"REGION": "us-east-1"})
+
+	if got := cfg.GetString("region"); got != "us-east-1" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "us-east-1")
+	}
+}
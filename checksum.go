@@ -0,0 +1,65 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+
+	"github.com/spf13/viper"
+)
+
+// configSum is the sha256 checksum of the config file when it was last read or written,
+// empty when there was no file. Guarded by mu.
+var configSum string
+
+// fileChecksum returns the hex encoded sha256 checksum of the file, or an empty string when it can't be read
+func fileChecksum(file string) string {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Checksum returns the sha256 checksum of the config file as it was loaded,
+// or an empty string when no config file was read
+func Checksum() string {
+	loadConfig()
+	mu.RLock()
+	defer mu.RUnlock()
+	return configSum
+}
+
+// Changed reports whether the config file on disk differs from the loaded config,
+// because it was edited, replaced or removed since it was last read or written
+func Changed() (bool, error) {
+	file := configFile()
+	mu.RLock()
+	sum := configSum
+	mu.RUnlock()
+	if file == "" {
+		return false, nil
+	}
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return sum != "", nil
+	}
+	if err != nil {
+		return false, err
+	}
+	current := sha256.Sum256(b)
+	return hex.EncodeToString(current[:]) != sum, nil
+}
+
+// configFile returns the config file that was loaded
+func configFile() string {
+	loadConfig()
+	mu.RLock()
+	defer mu.RUnlock()
+	return viper.ConfigFileUsed()
+}
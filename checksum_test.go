@@ -0,0 +1,63 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestChanged(t *testing.T) {
+
+	Reset()
+	defer Reset()
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(file, []byte("driftParam: first\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ReadInConfig()
+	viper.SetConfigFile(file)
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if Checksum() == "" {
+		t.Errorf("Expected a checksum")
+	}
+
+	if changed, err := Changed(); err != nil || changed {
+		t.Errorf("\ngot:  %v, %v\nwant: %v\n", changed, err, false)
+	}
+
+	if _, err := executeCommand(NewConfigCommand(), "doctor"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if err := ioutil.WriteFile(file, []byte("driftParam: second\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if changed, err := Changed(); err != nil || !changed {
+		t.Errorf("\ngot:  %v, %v\nwant: %v\n", changed, err, true)
+	}
+
+	if _, err := executeCommand(NewConfigCommand(), "doctor"); err == nil {
+		t.Errorf("Expected an error")
+	}
+
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if changed, err := Changed(); err != nil || changed {
+		t.Errorf("\ngot:  %v, %v\nwant: %v\n", changed, err, false)
+	}
+}
@@ -0,0 +1,421 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/bartdeboer/cobrahooks"
+	"github.com/iancoleman/strcase"
+	"github.com/imdario/mergo"
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// AddCollectionItem appends an item to the collection at collField and writes the config.
+// The item can be a struct or a map. Fails if an item with the same idField value already exists.
+func AddCollectionItem(collField string, idField string, item interface{}) error {
+	coll, err := loadCollection(collField)
+	if err != nil {
+		return err
+	}
+	m, err := itemToMap(item)
+	if err != nil {
+		return err
+	}
+	id, ok := m[idField]
+	if !ok {
+		return fmt.Errorf("item has no %q field", idField)
+	}
+	if i := findCollectionItem(coll, idField, fmt.Sprintf("%v", id), matchString); i >= 0 {
+		return fmt.Errorf("%s: item %v already exists", collField, id)
+	}
+	return saveCollection(collField, append(coll, m))
+}
+
+// UpdateCollectionItem overwrites the fields of the item in the collection where idField matches value
+// and writes the config.
+func UpdateCollectionItem(collField string, idField string, value string, item interface{}) error {
+	coll, err := loadCollection(collField)
+	if err != nil {
+		return err
+	}
+	i := findCollectionItem(coll, idField, value, matchString)
+	if i < 0 {
+		return &ErrItemNotFound{Collection: collField, ID: value}
+	}
+	m, err := itemToMap(item)
+	if err != nil {
+		return err
+	}
+	for k, v := range m {
+		for ek := range coll[i] {
+			if strings.EqualFold(ek, k) {
+				delete(coll[i], ek)
+			}
+		}
+		coll[i][k] = v
+	}
+	return saveCollection(collField, coll)
+}
+
+// RemoveCollectionItem removes the item from the collection where idField matches value
+// and writes the config.
+func RemoveCollectionItem(collField string, idField string, value string) error {
+	coll, err := loadCollection(collField)
+	if err != nil {
+		return err
+	}
+	i := findCollectionItem(coll, idField, value, matchString)
+	if i < 0 {
+		return &ErrItemNotFound{Collection: collField, ID: value}
+	}
+	return saveCollection(collField, append(coll[:i], coll[i+1:]...))
+}
+
+// loadCollection returns a copy of the items of the collection, so edits don't change the cached items
+func loadCollection(collField string) ([]map[string]interface{}, error) {
+	loadConfig()
+	c, err := cachedCollection(collField)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]map[string]interface{}, len(c.items))
+	for i, item := range c.items {
+		items[i] = deepCopy(item).(map[string]interface{})
+	}
+	return items, nil
+}
+
+func saveCollection(collField string, coll []map[string]interface{}) error {
+	tx := Begin()
+	tx.Set(collField, coll)
+	return tx.Commit()
+}
+
+// findCollectionItem returns the index of the item where idField matches value or -1
+func findCollectionItem(coll []map[string]interface{}, idField string, value string, match MatchFunc) int {
+	for i := 0; i < len(coll); i++ {
+		if val, ok := coll[i][idField]; ok && match(val, value) {
+			return i
+		}
+	}
+	return -1
+}
+
+// itemToMap converts a struct or map into a map with lowerCamel keys
+func itemToMap(item interface{}) (map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if err := mapstructure.Decode(item, &raw); err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		m[strcase.ToLowerCamel(k)] = v
+	}
+	return m, nil
+}
+
+// NewCollectionCommand creates a command with list, add, show, edit and remove subcommands
+// for managing the collection at collField. Flags for add and edit are generated from the item Struct.
+func NewCollectionCommand(collField string, rawVal interface{}, options ...func(*BindCollectionOptions)) *cobra.Command {
+	opts := newBindCollectionOptions(append(options, CollectionField(collField)))
+	var idField = opts.idField
+	completeId := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return opts.completeItems(cmd, args, toComplete)
+	}
+
+	c := &cobra.Command{
+		Use:   strcase.ToKebab(collField),
+		Short: fmt.Sprintf("Manage %s", collField),
+	}
+
+	c.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: fmt.Sprintf("List %s", collField),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			coll, err := loadCollection(collField)
+			if err != nil {
+				return err
+			}
+			for _, item := range coll {
+				fmt.Fprintln(cmd.OutOrStdout(), item[idField])
+			}
+			return nil
+		},
+	})
+
+	c.AddCommand(&cobra.Command{
+		Use:               "show <" + idField + ">",
+		Short:             "Show an item",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeId,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			coll, err := loadCollection(collField)
+			if err != nil {
+				return err
+			}
+			i := findCollectionItem(coll, idField, args[0], matchString)
+			if i < 0 {
+				return &ErrItemNotFound{Collection: collField, ID: args[0]}
+			}
+			keys := make([]string, 0, len(coll[i]))
+			for k := range coll[i] {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: %v\n", k, coll[i][k])
+			}
+			return nil
+		},
+	})
+
+	var interactive bool
+	addCmd := &cobra.Command{
+		Use:   "add <" + idField + ">",
+		Short: "Add an item",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if interactive {
+				return cobra.MaximumNArgs(1)(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interactive {
+				if fv := itemField(rawVal, idField); len(args) > 0 && fv.IsValid() {
+					if err := setFieldString(fv, args[0], nil); err != nil {
+						return err
+					}
+				}
+				if err := PromptStruct(cmd.InOrStdin(), cmd.OutOrStdout(), rawVal); err != nil {
+					return err
+				}
+			}
+			m, err := itemToMap(rawVal)
+			if err != nil {
+				return err
+			}
+			if len(args) > 0 {
+				m[idField] = args[0]
+			}
+			return AddCollectionItem(collField, idField, m)
+		},
+	}
+	createFlags(addCmd.Flags(), rawVal, FlagNaming)
+	addCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Prompt for the item values")
+	c.AddCommand(addCmd)
+
+	editCmd := &cobra.Command{
+		Use:               "edit <" + idField + ">",
+		Short:             "Edit an item",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeId,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return UpdateCollectionItem(collField, idField, args[0], changedFields(cmd.Flags(), rawVal, FlagNaming, strcase.ToLowerCamel))
+		},
+	}
+	createFlags(editCmd.Flags(), rawVal, FlagNaming)
+	c.AddCommand(editCmd)
+
+	removeCmd := &cobra.Command{
+		Use:               "remove <" + idField + ">",
+		Short:             "Remove an item",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeId,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := Confirm(cmd, fmt.Sprintf("Remove %s from %s?", args[0], collField)); err != nil {
+				return err
+			}
+			return RemoveCollectionItem(collField, idField, args[0])
+		},
+	}
+	AddConfirmFlags(removeCmd)
+	c.AddCommand(removeCmd)
+
+	return c
+}
+
+// changedFields returns the Struct values of the flags that were set on the command line by key name
+func changedFields(flags *pflag.FlagSet, rawVal interface{}, flagName NamingFunc, keyName NamingFunc) map[string]interface{} {
+	rv := structValue(rawVal)
+	m := make(map[string]interface{})
+	for _, f := range structFields(rv.Type()) {
+		if flag := flags.Lookup(flagName(f.name)); flag != nil && flag.Changed {
+			m[keyName(f.name)] = rv.Field(f.index).Interface()
+		}
+	}
+	return m
+}
+
+// BindCollection decodes the collection into a typed slice when running a Cobra command.
+// When BindTo and SelectField are given, the selected item is bound like BindCollectionItem.
+// The command fails when the items are not Structs with the IdField.
+func BindCollection[T any](c *cobra.Command, items *[]T, options ...func(*BindCollectionOptions)) {
+	opts := newBindCollectionOptions(options)
+	rt := reflect.TypeOf(items).Elem().Elem()
+	typeErr := checkItemType(rt, opts.idField)
+	if typeErr == nil {
+		registerCollection(&opts, rt, opts.bindTo != nil && opts.selectField != "")
+	}
+	var idField = opts.idField
+	var item *T
+	if opts.bindTo != nil {
+		var ok bool
+		if item, ok = opts.bindTo.(*T); !ok {
+			panic("BindTo value is not a pointer to the collection item type")
+		}
+		if typeErr == nil {
+			createFlags(c.PersistentFlags(), item, FlagNaming)
+			c.Flags().SortFlags = SortFlags
+			opts.createSelectorFlag(c)
+		}
+	}
+	cobrahooks.OnPersistentPreRun(c, func(cmd *cobra.Command, args []string) error {
+		if typeErr != nil {
+			return typeErr
+		}
+		loadConfig()
+		if opts.parent == nil {
+			mu.RLock()
+			err := viper.UnmarshalKey(opts.collectionField, items)
+			mu.RUnlock()
+			if err != nil {
+				return err
+			}
+		} else {
+			coll, err := opts.items()
+			if err != nil {
+				return err
+			}
+			if err := mapstructure.WeakDecode(coll, items); err != nil {
+				return err
+			}
+		}
+		if item == nil || opts.selectField == "" {
+			return nil
+		}
+		selectValue := opts.selectedValue()
+		if selectValue == "" {
+			selectValue = defaultItemId(*items, &opts)
+		}
+		var selected *T
+		for _, value := range opts.selectedValues(selectValue) {
+			found, ok := findItem(*items, idField, value, opts.matches)
+			if !ok {
+				if err := opts.missingItem(cmd, value, itemIds(*items, idField)); err != nil {
+					return err
+				}
+				continue
+			}
+			if selected == nil {
+				selected = &found
+			} else if err := mergo.MergeWithOverwrite(selected, found); err != nil {
+				return err
+			}
+		}
+		if selected == nil {
+			return nil
+		}
+		curVal := *item
+		*item = *selected
+		if err := mergo.MergeWithOverwrite(item, curVal); err != nil {
+			return err
+		}
+		setFlagDefaults(c.PersistentFlags(), item, FlagNaming)
+		return nil
+	}, cobrahooks.RunOnHelp)
+}
+
+// UnmarshalKeySlice decodes the collection at the key into typed items, with the same decode hooks
+// as bound Structs, like parsing durations and comma separated lists
+func UnmarshalKeySlice[T any](key string) ([]T, error) {
+	loadConfig()
+	traceRead(key)
+	mu.RLock()
+	value := viper.Get(key)
+	mu.RUnlock()
+	var items []T
+	if err := decode(value, &items); err != nil {
+		return nil, &ErrDecode{Key: key, Type: reflect.TypeOf(items).String(), Value: value, Err: err}
+	}
+	return items, nil
+}
+
+// FindItem returns the item where the idField Struct field matches value
+func FindItem[T any](items []T, idField string, value string) (T, bool) {
+	return findItem(items, idField, value, matchString)
+}
+
+func findItem[T any](items []T, idField string, value string, match MatchFunc) (T, bool) {
+	for _, item := range items {
+		if fv := itemField(item, idField); fv.IsValid() && match(fv.Interface(), value) {
+			return item, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// defaultItemId returns the id of the DefaultItem or the item with a true Default field
+func defaultItemId[T any](items []T, opts *BindCollectionOptions) string {
+	if opts.defaultItem != "" {
+		return opts.defaultItem
+	}
+	for _, item := range items {
+		if fv := itemField(item, "default"); fv.Kind() == reflect.Bool && fv.Bool() {
+			if id := itemField(item, opts.idField); id.IsValid() {
+				return fmt.Sprintf("%v", id.Interface())
+			}
+		}
+	}
+	return ""
+}
+
+// itemIds returns the idField values of the items
+func itemIds[T any](items []T, idField string) []string {
+	var ids []string
+	for _, item := range items {
+		if fv := itemField(item, idField); fv.IsValid() {
+			ids = append(ids, fmt.Sprintf("%v", fv.Interface()))
+		}
+	}
+	return ids
+}
+
+// itemField returns the Struct field matching name case-insensitively,
+// or an invalid value when the item is not a Struct or has no such field
+func itemField(item interface{}, name string) reflect.Value {
+	rv := reflect.Indirect(reflect.ValueOf(item))
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	return rv.FieldByNameFunc(func(n string) bool {
+		return strings.EqualFold(n, name)
+	})
+}
+
+// checkItemType checks that the collection items are Structs with the id field
+func checkItemType(rt reflect.Type, idField string) error {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		return fmt.Errorf("collection items of type %s are not structs", rt)
+	}
+	if _, ok := rt.FieldByNameFunc(func(n string) bool { return strings.EqualFold(n, idField) }); !ok {
+		return fmt.Errorf("collection item type %s has no %q field", rt, idField)
+	}
+	return nil
+}
@@ -0,0 +1,786 @@
+package cfg
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type serverStruct struct {
+	Name string
+	Host string
+	Port int
+}
+
+func TestCollectionCRUD(t *testing.T) {
+
+	file := useConfigFile(t, "servers:\n  - name: first\n    host: first.example.com\n    port: 80\n")
+
+	if err := AddCollectionItem("servers", "name", serverStruct{Name: "second", Host: "second.example.com", Port: 443}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if err := AddCollectionItem("servers", "name", serverStruct{Name: "second"}); err == nil {
+		t.Errorf("Expected error adding a duplicate item")
+	}
+
+	if err := UpdateCollectionItem("servers", "name", "first", serverStruct{Name: "first", Host: "updated.example.com", Port: 8080}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	var servers []serverStruct
+	if err := viper.UnmarshalKey("servers", &servers); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	want := []serverStruct{
+		{Name: "first", Host: "updated.example.com", Port: 8080},
+		{Name: "second", Host: "second.example.com", Port: 443},
+	}
+
+	if len(servers) != len(want) || servers[0] != want[0] || servers[1] != want[1] {
+		t.Errorf("\ngot:  %v\nwant: %v\n", servers, want)
+	}
+
+	if err := RemoveCollectionItem("servers", "name", "first"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if err := RemoveCollectionItem("servers", "name", "first"); err == nil {
+		t.Errorf("Expected error removing a missing item")
+	}
+
+	servers = nil
+	if err := viper.UnmarshalKey("servers", &servers); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if len(servers) != 1 || servers[0] != want[1] {
+		t.Errorf("\ngot:  %v\nwant: %v\n", servers, want[1:])
+	}
+
+	if b, err := os.ReadFile(file); err != nil || !strings.Contains(string(b), "second.example.com") {
+		t.Errorf("Config was not written: %v", err)
+	}
+}
+
+type remoteStruct struct {
+	Name string
+	Url  string
+	Tls  bool
+}
+
+func TestLoadCollectionCopy(t *testing.T) {
+
+	useConfigFile(t, "servers:\n  - name: first\n  - name: second\n")
+
+	coll, err := loadCollection("servers")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	coll[0]["name"] = "changed"
+	_ = append(coll[:0], coll[1:]...)
+
+	c, err := cachedCollection("servers")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []map[string]interface{}{{"name": "first"}, {"name": "second"}}
+	if !reflect.DeepEqual(c.items, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", c.items, want)
+	}
+}
+
+func TestCollectionCommand(t *testing.T) {
+
+	useConfigFile(t, "remotes: []\n")
+
+	var remote remoteStruct
+
+	rootCmd := &cobra.Command{Use: "root"}
+	rootCmd.AddCommand(NewCollectionCommand("remotes", &remote))
+
+	if _, err := executeCommand(rootCmd, "remotes", "add", "origin", "--url", "https://example.com"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if _, err := executeCommand(rootCmd, "remotes", "add", "upstream", "--url", "https://example.org"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if _, err := executeCommand(rootCmd, "remotes", "edit", "origin", "--tls"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if _, err := executeCommand(rootCmd, "remotes", "remove", "upstream", "--yes"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	output, err := executeCommand(rootCmd, "remotes", "list")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if output != "origin\n" {
+		t.Errorf("\ngot:  %q\nwant: %q\n", output, "origin\n")
+	}
+
+	output, err = executeCommand(rootCmd, "remotes", "show", "origin")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	want := "name: origin\ntls: true\nurl: https://example.com\n"
+	if output != want {
+		t.Errorf("\ngot:  %q\nwant: %q\n", output, want)
+	}
+}
+
+func TestRunBoundTypedCollectionCommand(t *testing.T) {
+
+	t.Cleanup(Reset)
+	var (
+		rootConfig collRootStruct
+		items      []itemStruct
+		itemConfig itemStruct
+	)
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	childCmd := &cobra.Command{
+		Use: "child",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	rootCmd.AddCommand(childCmd)
+
+	BindPersistentFlags(rootCmd, &rootConfig)
+	BindCollection(childCmd, &items, CollectionField("collection"), SelectField("CollectionSelectedItem"), BindTo(&itemConfig))
+
+	if _, err := executeCommand(rootCmd, "child", "--eighth-param", "SecondEighthFlag"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if len(items) != 3 || items[2].Name != "ThirdItem" {
+		t.Errorf("Unexpected items: %v", items)
+	}
+
+	itemTest := itemStruct{
+		SeventhParam: true,
+		EighthParam:  "SecondEighthFlag",
+		Name:         "SecondItem",
+	}
+
+	if itemConfig != itemTest {
+		t.Errorf("\ngot:  %v\nwant: %v\n", itemConfig, itemTest)
+	}
+}
+
+type numberedItemStruct struct {
+	Id    int
+	Label string
+}
+
+func TestRunBoundCollectionNumericId(t *testing.T) {
+
+	t.Cleanup(Reset)
+	var (
+		itemConfig  numberedItemStruct
+		labelConfig numberedItemStruct
+	)
+
+	coll := []map[string]interface{}{
+		{"id": 1, "label": "One"},
+		{"id": 2, "label": "Two"},
+	}
+
+	Set("numberedSelectedItem", "2")
+	Set("labelSelectedItem", "ONE")
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	childCmd := &cobra.Command{
+		Use: "child",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	rootCmd.AddCommand(childCmd)
+
+	BindCollectionItem(rootCmd, &itemConfig, Collection(&coll), IdField("id"), SelectField("numberedSelectedItem"))
+	BindCollectionItem(childCmd, &labelConfig, Collection(&coll), IdField("label"), SelectField("labelSelectedItem"),
+		Match(func(id interface{}, value string) bool {
+			return strings.EqualFold(id.(string), value)
+		}))
+
+	if _, err := executeCommand(rootCmd, "child"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if want := (numberedItemStruct{Id: 2, Label: "Two"}); itemConfig != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", itemConfig, want)
+	}
+
+	if want := (numberedItemStruct{Id: 1, Label: "One"}); labelConfig != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", labelConfig, want)
+	}
+}
+
+type envStruct struct {
+	Name   string
+	Region string
+}
+
+func TestRunBoundCollectionMatching(t *testing.T) {
+
+	t.Cleanup(Reset)
+	var (
+		foldConfig   envStruct
+		prefixConfig envStruct
+	)
+
+	coll := []map[string]interface{}{
+		{"name": "prod", "region": "eu-west-1"},
+		{"name": "staging", "region": "eu-central-1"},
+	}
+
+	Set("foldSelectedEnv", "Prod")
+	Set("prefixSelectedEnv", "stag")
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	childCmd := &cobra.Command{
+		Use: "child",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	rootCmd.AddCommand(childCmd)
+
+	BindCollectionItem(rootCmd, &foldConfig, Collection(&coll), SelectField("foldSelectedEnv"), IgnoreCase)
+	BindCollectionItem(childCmd, &prefixConfig, Collection(&coll), SelectField("prefixSelectedEnv"), MatchPrefix)
+
+	if _, err := executeCommand(rootCmd, "child"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if want := (envStruct{Name: "prod", Region: "eu-west-1"}); foldConfig != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", foldConfig, want)
+	}
+
+	if want := (envStruct{Name: "staging", Region: "eu-central-1"}); prefixConfig != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", prefixConfig, want)
+	}
+}
+
+func TestRunBoundCollectionMissingItem(t *testing.T) {
+
+	t.Cleanup(Reset)
+	var (
+		itemConfig  envStruct
+		typedConfig envStruct
+		items       []envStruct
+	)
+
+	coll := []map[string]interface{}{
+		{"name": "prod", "region": "eu-west-1"},
+		{"name": "staging", "region": "eu-central-1"},
+	}
+
+	Set("missingSelectedEnv", "dev")
+	Set("missingCollection", coll)
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	childCmd := &cobra.Command{
+		Use: "child",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	rootCmd.AddCommand(childCmd)
+
+	BindCollectionItem(rootCmd, &itemConfig, Collection(&coll), SelectField("missingSelectedEnv"), WarnMissingItem)
+
+	output, err := executeCommand(rootCmd)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	want := "Warning: collection: item \"dev\" not found (available: prod, staging)\n"
+	if output != want {
+		t.Errorf("\ngot:  %q\nwant: %q\n", output, want)
+	}
+
+	BindCollection(childCmd, &items, CollectionField("missingCollection"), SelectField("missingSelectedEnv"), BindTo(&typedConfig), RequireItem)
+
+	_, err = executeCommand(rootCmd, "child")
+	if err == nil || !strings.Contains(err.Error(), "missingCollection: item \"dev\" not found (available: prod, staging)") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+type profileStruct struct {
+	Name    string
+	Host    string
+	Default bool
+}
+
+func TestRunBoundCollectionDefaultItem(t *testing.T) {
+
+	t.Cleanup(Reset)
+	var (
+		markedConfig profileStruct
+		optionConfig profileStruct
+		typedConfig  profileStruct
+		items        []profileStruct
+	)
+
+	coll := []map[string]interface{}{
+		{"name": "local", "host": "localhost"},
+		{"name": "remote", "host": "example.com", "default": true},
+	}
+
+	Set("defaultProfiles", coll)
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	child1Cmd := &cobra.Command{
+		Use: "child1",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	child2Cmd := &cobra.Command{
+		Use: "child2",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	rootCmd.AddCommand(child1Cmd)
+	child1Cmd.AddCommand(child2Cmd)
+
+	BindCollectionItem(rootCmd, &markedConfig, Collection(&coll), SelectField("unsetSelectedProfile"))
+	BindCollectionItem(child1Cmd, &optionConfig, Collection(&coll), SelectField("unsetSelectedProfile"), DefaultItem("local"))
+	BindCollection(child2Cmd, &items, CollectionField("defaultProfiles"), SelectField("unsetSelectedProfile"), BindTo(&typedConfig))
+
+	if _, err := executeCommand(rootCmd, "child1", "child2"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if want := (profileStruct{Name: "remote", Host: "example.com", Default: true}); markedConfig != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", markedConfig, want)
+	}
+
+	if want := (profileStruct{Name: "local", Host: "localhost"}); optionConfig != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", optionConfig, want)
+	}
+
+	if want := (profileStruct{Name: "remote", Host: "example.com", Default: true}); typedConfig != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", typedConfig, want)
+	}
+}
+
+func TestRunBoundCollectionSelectorFlag(t *testing.T) {
+
+	t.Cleanup(Reset)
+	var itemConfig envStruct
+
+	coll := []map[string]interface{}{
+		{"name": "prod", "region": "eu-west-1"},
+		{"name": "staging", "region": "eu-central-1"},
+	}
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindCollectionItem(rootCmd, &itemConfig, Collection(&coll), SelectField("env"), SelectorFlag)
+
+	if _, err := executeCommand(rootCmd, "--env", "staging"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if want := (envStruct{Name: "staging", Region: "eu-central-1"}); itemConfig != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", itemConfig, want)
+	}
+
+	output, err := executeCommand(rootCmd, "__complete", "--env", "")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(output, "prod\nstaging\n") {
+		t.Errorf("Unexpected completion: %q", output)
+	}
+}
+
+type layeredStruct struct {
+	Name    string
+	Region  string
+	Replica int
+	Debug   bool
+}
+
+func TestRunBoundCollectionMultiSelect(t *testing.T) {
+
+	t.Cleanup(Reset)
+	var (
+		itemConfig  layeredStruct
+		typedConfig layeredStruct
+		items       []layeredStruct
+	)
+
+	coll := []map[string]interface{}{
+		{"name": "base", "region": "eu-west-1", "replica": 1, "debug": true},
+		{"name": "prod", "replica": 3},
+	}
+
+	Set("layeredProfiles", coll)
+	Set("selectedProfiles", "base, prod")
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	childCmd := &cobra.Command{
+		Use: "child",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	rootCmd.AddCommand(childCmd)
+
+	BindCollectionItem(rootCmd, &itemConfig, Collection(&coll), SelectField("selectedProfiles"), MultiSelect)
+	BindCollection(childCmd, &items, CollectionField("layeredProfiles"), SelectField("selectedProfiles"), BindTo(&typedConfig), MultiSelect)
+
+	if _, err := executeCommand(rootCmd, "child"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	want := layeredStruct{Name: "prod", Region: "eu-west-1", Replica: 3, Debug: true}
+
+	if itemConfig != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", itemConfig, want)
+	}
+
+	if typedConfig != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", typedConfig, want)
+	}
+}
+
+type nodeStruct struct {
+	Name    string
+	Address string
+}
+
+func TestRunBoundNestedCollection(t *testing.T) {
+
+	t.Cleanup(Reset)
+	var (
+		nodeConfig  nodeStruct
+		typedConfig nodeStruct
+		nodes       []nodeStruct
+	)
+
+	Set("clusters", []map[string]interface{}{
+		{"name": "east", "nodes": []map[string]interface{}{
+			{"name": "node1", "address": "10.0.0.1"},
+			{"name": "node2", "address": "10.0.0.2"},
+		}},
+		{"name": "west", "nodes": []map[string]interface{}{
+			{"name": "node1", "address": "10.1.0.1"},
+		}},
+	})
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	childCmd := &cobra.Command{
+		Use: "child",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	rootCmd.AddCommand(childCmd)
+
+	BindCollectionItem(rootCmd, &nodeConfig, CollectionField("nodes"), SelectField("node"), SelectorFlag,
+		Within(CollectionField("clusters"), SelectField("cluster"), SelectorFlag))
+	BindCollection(childCmd, &nodes, CollectionField("nodes"), SelectField("node"), BindTo(&typedConfig),
+		Within(CollectionField("clusters"), SelectField("cluster")))
+
+	if _, err := executeCommand(rootCmd, "--cluster", "west", "--node", "node1"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if want := (nodeStruct{Name: "node1", Address: "10.1.0.1"}); nodeConfig != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", nodeConfig, want)
+	}
+
+	Set("cluster", "east")
+	Set("node", "node2")
+
+	if _, err := executeCommand(rootCmd, "child", "--cluster", "", "--node", ""); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if len(nodes) != 2 {
+		t.Errorf("Unexpected nodes: %v", nodes)
+	}
+
+	if want := (nodeStruct{Name: "node2", Address: "10.0.0.2"}); typedConfig != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", typedConfig, want)
+	}
+
+}
+
+func TestRunNestedCollectionWithoutParentItem(t *testing.T) {
+
+	t.Cleanup(Reset)
+	var (
+		nodeConfig     nodeStruct
+		requiredConfig nodeStruct
+	)
+
+	Set("clusters", []map[string]interface{}{
+		{"name": "east", "nodes": []map[string]interface{}{
+			{"name": "node1", "address": "10.0.0.1"},
+		}},
+	})
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	childCmd := &cobra.Command{
+		Use: "child",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	rootCmd.AddCommand(childCmd)
+
+	BindCollectionItem(rootCmd, &nodeConfig, CollectionField("nodes"), SelectField("node"), SelectorFlag,
+		Within(CollectionField("clusters"), SelectField("cluster"), SelectorFlag))
+	BindCollectionItem(childCmd, &requiredConfig, CollectionField("nodes"), SelectField("node"),
+		Within(CollectionField("clusters"), SelectField("cluster"), RequireItem))
+
+	for _, args := range [][]string{{}, {"--cluster", "north", "--node", "node1"}} {
+		if _, err := executeCommand(rootCmd, args...); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+
+	if want := (nodeStruct{}); nodeConfig != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", nodeConfig, want)
+	}
+
+	if _, err := executeCommand(rootCmd, "child"); err == nil {
+		t.Errorf("Expected error without a required parent item")
+	}
+}
+
+func TestCollectionItemCompletion(t *testing.T) {
+
+	t.Cleanup(Reset)
+	var (
+		rootConfig collRootStruct
+		itemConfig itemStruct
+		remote     remoteStruct
+	)
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	childCmd := &cobra.Command{
+		Use: "child",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	rootCmd.AddCommand(childCmd)
+	rootCmd.AddCommand(NewCollectionCommand("collection", &remote))
+
+	BindPersistentFlags(rootCmd, &rootConfig)
+	BindCollectionItemFields("collection", "CollectionSelectedItem", childCmd, &itemConfig)
+
+	output, err := executeCommand(rootCmd, "__complete", "child", "--collection-selected-item", "")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if want := "FirstItem\nSecondItem\nThirdItem\n"; !strings.HasPrefix(output, want) {
+		t.Errorf("\ngot:  %q\nwant: %q\n", output, want)
+	}
+
+	output, err = executeCommand(rootCmd, "__complete", "collection", "show", "")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if want := "FirstItem\nSecondItem\nThirdItem\n"; !strings.HasPrefix(output, want) {
+		t.Errorf("\ngot:  %q\nwant: %q\n", output, want)
+	}
+}
+
+type targetStruct struct {
+	Name string
+	Host string
+}
+
+func TestRunItemCommands(t *testing.T) {
+
+	t.Cleanup(Reset)
+	var (
+		targetConfig targetStruct
+		deployed     []targetStruct
+	)
+
+	Set("targets", []map[string]interface{}{
+		{"name": "prod", "host": "prod.example.com"},
+		{"name": "staging", "host": "staging.example.com", "default": true},
+	})
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	deployCmd := &cobra.Command{
+		Use: "deploy",
+		Run: func(_ *cobra.Command, _ []string) {
+			deployed = append(deployed, targetConfig)
+		},
+	}
+
+	rootCmd.AddCommand(deployCmd)
+
+	BindCollectionItem(deployCmd, &targetConfig, CollectionField("targets"), ItemCommands)
+
+	if _, err := executeCommand(rootCmd, "deploy", "prod", "--host", "override.example.com"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	targetConfig = targetStruct{}
+
+	if _, err := executeCommand(rootCmd, "deploy", "staging"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	want := []targetStruct{
+		{Name: "prod", Host: "override.example.com"},
+		{Name: "staging", Host: "staging.example.com"},
+	}
+
+	if len(deployed) != 2 || deployed[0] != want[0] || deployed[1] != want[1] {
+		t.Errorf("\ngot:  %v\nwant: %v\n", deployed, want)
+	}
+}
+
+func TestCollectionCommandInteractiveAdd(t *testing.T) {
+
+	useConfigFile(t, "interactiveRemotes: []\n")
+
+	var remote remoteStruct
+
+	rootCmd := &cobra.Command{Use: "root"}
+	rootCmd.AddCommand(NewCollectionCommand("interactiveRemotes", &remote))
+	rootCmd.SetIn(strings.NewReader("\nhttps://example.com\ntrue\n"))
+
+	if _, err := executeCommand(rootCmd, "interactive-remotes", "add", "origin", "-i"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	var remotes []remoteStruct
+	if err := viper.UnmarshalKey("interactiveRemotes", &remotes); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	want := remoteStruct{Name: "origin", Url: "https://example.com", Tls: true}
+	if len(remotes) != 1 || remotes[0] != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", remotes, want)
+	}
+}
+
+type timedServer struct {
+	Name    string
+	Timeout time.Duration
+	Tags    []string
+}
+
+func TestUnmarshalKeySlice(t *testing.T) {
+
+	t.Cleanup(Reset)
+	Set("timedServers", []interface{}{
+		map[string]interface{}{"name": "prod", "timeout": "5s", "tags": "eu,primary"},
+		map[string]interface{}{"name": "dev", "timeout": "1m"},
+	})
+
+	servers, err := UnmarshalKeySlice[timedServer]("timedServers")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []timedServer{
+		{Name: "prod", Timeout: 5 * time.Second, Tags: []string{"eu", "primary"}},
+		{Name: "dev", Timeout: time.Minute},
+	}
+	if !reflect.DeepEqual(servers, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", servers, want)
+	}
+}
+
+type defaultOnlyStruct struct {
+	Label   string
+	Default bool
+}
+
+func TestBindCollectionItemType(t *testing.T) {
+
+	t.Cleanup(Reset)
+	var (
+		names  []string
+		labels []defaultOnlyStruct
+	)
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+	BindCollection(rootCmd, &names, CollectionField("collection"))
+
+	if _, err := executeCommand(rootCmd); err == nil || !strings.Contains(err.Error(), "not structs") {
+		t.Errorf("Expected an error for non-struct items: %v", err)
+	}
+
+	rootCmd = &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+	BindCollection(rootCmd, &labels, CollectionField("collection"))
+
+	if _, err := executeCommand(rootCmd); err == nil || !strings.Contains(err.Error(), `no "name" field`) {
+		t.Errorf("Expected an error for a missing id field: %v", err)
+	}
+
+	if got := defaultItemId([]defaultOnlyStruct{{Label: "first", Default: true}}, &BindCollectionOptions{idField: "name"}); got != "" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "")
+	}
+}
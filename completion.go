@@ -0,0 +1,218 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// flagFields holds the Struct field of each generated flag
+var flagFields sync.Map
+
+// EnableCompletion adds a completion command to the root command and completes the values
+// of the generated flags in the command tree: fields with a `choices:"a,b"` tag complete the choices,
+// fields with a `complete:"file"` or `complete:"dir"` tag complete paths using the shell.
+// Call it after binding the Structs.
+func EnableCompletion(root *cobra.Command) {
+	registerCompletions(root)
+	root.AddCommand(newCompletionCommand(root))
+}
+
+// registerCompletions registers the value completions of the generated flags of the command and its subcommands
+func registerCompletions(c *cobra.Command) {
+	register := func(flag *pflag.Flag) {
+		v, ok := flagFields.Load(flag)
+		if !ok {
+			return
+		}
+		if complete := fieldCompletion(v.(fieldInfo)); complete != nil {
+			// Flags that already complete, like collection selectors, return an error
+			_ = c.RegisterFlagCompletionFunc(flag.Name, complete)
+		}
+	}
+	c.LocalNonPersistentFlags().VisitAll(register)
+	c.PersistentFlags().VisitAll(register)
+	for _, sub := range c.Commands() {
+		registerCompletions(sub)
+	}
+}
+
+// fieldCompletion returns the completion function for the values of a field, if any
+func fieldCompletion(f fieldInfo) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	if len(f.choices) > 0 {
+		return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return f.choices, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	switch f.complete {
+	case "file", "dir":
+		return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+	}
+	return nil
+}
+
+// schemaKeys holds the config keys of the bound Structs, to complete keys that are not set yet
+var schemaKeys = struct {
+	sync.Mutex
+	keys        map[string]bool
+	secrets     map[string]bool
+	lazy        map[string]bool
+	bindings    []schemaBinding
+	collections []collectionBinding
+}{keys: make(map[string]bool), secrets: make(map[string]bool), lazy: make(map[string]bool)}
+
+// resetSchema forgets the bound Structs and collections
+func resetSchema() {
+	schemaKeys.Lock()
+	defer schemaKeys.Unlock()
+	schemaKeys.keys = make(map[string]bool)
+	schemaKeys.secrets = make(map[string]bool)
+	schemaKeys.lazy = make(map[string]bool)
+	schemaKeys.bindings = nil
+	schemaKeys.collections = nil
+}
+
+// schemaBinding is a Struct bound at a config key, to validate config edits against
+type schemaBinding struct {
+	key     string
+	rt      reflect.Type
+	keyName NamingFunc
+}
+
+// registerSchema adds the config keys of the Struct fields at the key
+func registerSchema(key string, rt reflect.Type, keyName NamingFunc) {
+	schemaKeys.Lock()
+	defer schemaKeys.Unlock()
+	schemaKeys.bindings = append(schemaKeys.bindings, schemaBinding{key: strings.ToLower(key), rt: rt, keyName: keyName})
+	registerSchemaFields(strings.ToLower(key), rt, keyName)
+}
+
+func registerSchemaFields(key string, rt reflect.Type, keyName NamingFunc) {
+	for _, f := range structFields(rt) {
+		if !f.exported {
+			continue
+		}
+		fieldKey := strings.ToLower(keyName(f.name))
+		if key != "" {
+			fieldKey = key + "." + fieldKey
+		}
+		if f.kind == reflect.Struct && f.typ != secretType {
+			registerSchemaFields(fieldKey, f.typ, func(name string) string { return name })
+			continue
+		}
+		schemaKeys.keys[fieldKey] = true
+		if f.secret {
+			schemaKeys.secrets[fieldKey] = true
+		}
+		if f.typ == secretType {
+			schemaKeys.lazy[fieldKey] = true
+		}
+	}
+}
+
+// schemaType returns the type of the Struct field bound at the key
+func schemaType(key string) (reflect.Type, bool) {
+	key = strings.ToLower(key)
+	schemaKeys.Lock()
+	defer schemaKeys.Unlock()
+	for _, b := range schemaKeys.bindings {
+		if rt, ok := fieldType(b.key, b.rt, b.keyName, key); ok {
+			return rt, true
+		}
+	}
+	return nil, false
+}
+
+// fieldType returns the type of the field of the Struct at the prefix that has the key
+func fieldType(prefix string, rt reflect.Type, keyName NamingFunc, key string) (reflect.Type, bool) {
+	for _, f := range structFields(rt) {
+		if !f.exported {
+			continue
+		}
+		fieldKey := strings.ToLower(keyName(f.name))
+		if prefix != "" {
+			fieldKey = prefix + "." + fieldKey
+		}
+		if f.kind == reflect.Struct && f.typ != secretType {
+			if ft, ok := fieldType(fieldKey, f.typ, func(name string) string { return name }, key); ok {
+				return ft, true
+			}
+			continue
+		}
+		if fieldKey == key {
+			return f.typ, true
+		}
+	}
+	return nil, false
+}
+
+// completeKeys completes the dot-separated config keys one level at a time
+// from the config and the bound Structs
+func completeKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	loadConfig()
+	mu.RLock()
+	keys := allKeys()
+	mu.RUnlock()
+	schemaKeys.Lock()
+	for key := range schemaKeys.keys {
+		keys = append(keys, key)
+	}
+	schemaKeys.Unlock()
+	prefix := strings.ToLower(toComplete)
+	seen := make(map[string]bool)
+	var matches []string
+	directive := cobra.ShellCompDirectiveNoFileComp
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if i := strings.Index(key[len(prefix):], "."); i >= 0 {
+			key = key[:len(prefix)+i+1]
+			directive |= cobra.ShellCompDirectiveNoSpace
+		}
+		if !seen[key] {
+			seen[key] = true
+			matches = append(matches, key)
+		}
+	}
+	sort.Strings(matches)
+	return matches, directive
+}
+
+// newCompletionCommand creates the command generating the completion scripts
+func newCompletionCommand(root *cobra.Command) *cobra.Command {
+	return &cobra.Command{
+		Use:       "completion [bash|zsh|fish|powershell]",
+		Short:     "Generate the shell completion script",
+		Args:      cobra.ExactValidArgs(1),
+		ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletion(out)
+			case "zsh":
+				return root.GenZshCompletion(out)
+			case "fish":
+				return root.GenFishCompletion(out, true)
+			case "powershell":
+				return root.GenPowerShellCompletion(out)
+			}
+			return fmt.Errorf("unsupported shell %q", args[0])
+		},
+	}
+}
@@ -0,0 +1,58 @@
+package cfg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+type completedStruct struct {
+	Mode   string `choices:"fast,slow"`
+	Output string `complete:"file"`
+}
+
+func TestEnableCompletion(t *testing.T) {
+
+	var config completedStruct
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+	}
+
+	childCmd := &cobra.Command{
+		Use: "child",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	rootCmd.AddCommand(childCmd)
+	BindFlags(childCmd, &config, NoViper)
+	EnableCompletion(rootCmd)
+
+	output, err := executeCommand(rootCmd, "__complete", "child", "--mode", "")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(output, "fast\nslow\n:4\n") {
+		t.Errorf("Unexpected completion: %q", output)
+	}
+
+	output, err = executeCommand(rootCmd, "__complete", "child", "--output", "")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(output, ":0\n") {
+		t.Errorf("Unexpected completion: %q", output)
+	}
+
+	output, err = executeCommand(rootCmd, "completion", "bash")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(output, "bash completion for root") {
+		t.Errorf("Unexpected script: %.80q", output)
+	}
+}
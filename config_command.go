@@ -0,0 +1,178 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// NewConfigCommand creates a config command with subcommands for maintaining the config file
+func NewConfigCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the config file",
+	}
+
+	c.AddCommand(newInitCommand())
+	c.AddCommand(newFmtCommand())
+	c.AddCommand(newShowCommand())
+	c.AddCommand(newTUICommand())
+
+	c.AddCommand(&cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade the config file to the latest version",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, to, err := Migrate()
+			if err != nil {
+				return err
+			}
+			if from == to {
+				fmt.Fprintf(cmd.OutOrStdout(), "Config is up to date (version %d)\n", to)
+				return nil
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Migrated config from version %d to %d\n", from, to)
+			return writeConfigFile()
+		},
+	})
+
+	c.AddCommand(&cobra.Command{
+		Use:   "doctor",
+		Short: "Check the config file",
+		Long:  "Check the config file, report when it changed on disk since it was loaded and warn about keys no bound Struct reads.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file := configFile()
+			if file == "" {
+				fmt.Fprintln(cmd.OutOrStdout(), "No config file")
+				return nil
+			}
+			changed, err := Changed()
+			if err != nil {
+				return err
+			}
+			if changed {
+				return fmt.Errorf("config file %s changed on disk since it was loaded", file)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Config file %s is up to date (sha256 %s)\n", file, Checksum())
+			for _, key := range UnknownKeys() {
+				fmt.Fprintf(cmd.OutOrStdout(), "Warning: %s\n", key)
+			}
+			return nil
+		},
+	})
+
+	c.AddCommand(&cobra.Command{
+		Use:               "get <key>",
+		Short:             "Print a config value",
+		Long:              "Print a config value. List items can be selected like collection[1].name or collection[name=item].name.",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeKeys,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			value, err := Query(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), value)
+			return nil
+		},
+	})
+
+	setCmd := &cobra.Command{
+		Use:               "set <key> <value>",
+		Short:             "Set a config value and write the config file",
+		Long:              "Set a config value and write the config file. Changing a value that is set asks for confirmation on terminals.",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeKeys,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			loadConfig()
+			mu.RLock()
+			suggestion := suggestPath(args[0])
+			mu.RUnlock()
+			if suggestion != "" {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", &ErrUnknownKey{Key: args[0], Suggestion: suggestion})
+			}
+			if current, err := Query(args[0]); err == nil && fmt.Sprintf("%v", current) != args[1] {
+				if err := Confirm(cmd, fmt.Sprintf("Overwrite %s?", args[0])); err != nil {
+					return err
+				}
+			}
+			tx := Begin()
+			tx.Set(args[0], parseValue(args[0], args[1]))
+			return tx.Commit()
+		},
+	}
+	AddConfirmFlags(setCmd)
+	c.AddCommand(setCmd)
+
+	unsetCmd := &cobra.Command{
+		Use:               "unset <key>",
+		Short:             "Remove a config value and write the config file",
+		Long:              "Remove a config value and write the config file. Asks for confirmation on terminals.",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeKeys,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := Confirm(cmd, fmt.Sprintf("Remove %s?", args[0])); err != nil {
+				return err
+			}
+			tx := Begin()
+			tx.Unset(args[0])
+			return tx.Commit()
+		},
+	}
+	AddConfirmFlags(unsetCmd)
+	c.AddCommand(unsetCmd)
+
+	return c
+}
+
+// parseValue parses a command line value by the type of the Struct field bound at the key,
+// or else by the YAML 1.2 core schema, so only true, false and numbers lose their quotes.
+// Other values like "yes", "on" or "1.10" stay strings.
+func parseValue(key string, s string) interface{} {
+	if rt, ok := schemaType(key); ok {
+		return parseKind(rt.Kind(), s)
+	}
+	switch s {
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if i, err := strconv.Atoi(s); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strconv.FormatFloat(f, 'f', -1, 64) == s {
+		return f
+	}
+	return s
+}
+
+// parseKind parses the value as the kind, returning the string when it doesn't parse
+func parseKind(kind reflect.Kind, s string) interface{} {
+	switch kind {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return u
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	return s
+}
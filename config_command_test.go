@@ -0,0 +1,176 @@
+package cfg
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func TestConfigGetSet(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	viper.SetConfigFile(filepath.Join(dir, "config.yaml"))
+
+	if _, err := executeCommand(NewConfigCommand(), "set", "getSetSection.port", "8080"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if got := Get("getSetSection.port"); got != 8080 {
+		t.Errorf("\ngot:  %#v\nwant: %#v\n", got, 8080)
+	}
+
+	output, err := executeCommand(NewConfigCommand(), "get", "getSetSection.port")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if output != "8080\n" {
+		t.Errorf("\ngot:  %q\nwant: %q\n", output, "8080\n")
+	}
+
+	if _, err := executeCommand(NewConfigCommand(), "get", "getSetSection.missing"); err == nil {
+		t.Errorf("Expected an error")
+	}
+
+	output, err = executeCommand(NewConfigCommand(), "__complete", "get", "getset")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(output, "getsetsection.\n:6\n") {
+		t.Errorf("Unexpected completion: %q", output)
+	}
+
+	output, err = executeCommand(NewConfigCommand(), "__complete", "get", "getsetsection.")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(output, "getsetsection.port\n:4\n") {
+		t.Errorf("Unexpected completion: %q", output)
+	}
+
+	if _, err := executeCommand(NewConfigCommand(), "unset", "getSetSection.port"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if got := Get("getSetSection.port"); got != nil {
+		t.Errorf("\ngot:  %#v\nwant: %#v\n", got, nil)
+	}
+
+	if _, err := executeCommand(NewConfigCommand(), "unset", "getSetSection.port"); err == nil {
+		t.Errorf("Expected an error")
+	}
+}
+
+type schemaStruct struct {
+	ListenAddress string
+	Limits        struct {
+		MaxConns int
+	}
+}
+
+func TestConfigKeyCompletionFromSchema(t *testing.T) {
+
+	var config schemaStruct
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, Key("schemaSection"))
+
+	output, err := executeCommand(NewConfigCommand(), "__complete", "set", "schemasection.")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(output, "schemasection.limits.\nschemasection.listenaddress\n:6\n") {
+		t.Errorf("Unexpected completion: %q", output)
+	}
+}
+
+func TestConfigCommandGetQuery(t *testing.T) {
+
+	output, err := executeCommand(NewConfigCommand(), "get", "collection[name=FirstItem].eighthParam")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if want := "FirstEighth\n"; output != want {
+		t.Errorf("\ngot:  %q\nwant: %q\n", output, want)
+	}
+}
+
+func TestParseValue(t *testing.T) {
+
+	t.Cleanup(Reset)
+	var config struct {
+		Enabled string
+		Retries uint
+	}
+	BindFlags(&cobra.Command{Use: "root"}, &config, Key("parseSection"))
+
+	for _, test := range []struct {
+		key   string
+		value string
+		want  interface{}
+	}{
+		{"other", "true", true},
+		{"other", "false", false},
+		{"other", "yes", "yes"},
+		{"other", "on", "on"},
+		{"other", "8080", 8080},
+		{"other", "1.5", 1.5},
+		{"other", "1.10", "1.10"},
+		{"parseSection.enabled", "true", "true"},
+		{"parseSection.retries", "3", uint64(3)},
+		{"parseSection.retries", "many", "many"},
+	} {
+		if got := parseValue(test.key, test.value); got != test.want {
+			t.Errorf("%s %s\ngot:  %#v\nwant: %#v\n", test.key, test.value, got, test.want)
+		}
+	}
+}
+
+func TestConfigSetConfirm(t *testing.T) {
+
+	file := useConfigFile(t, "confirmSection:\n  port: 80\n")
+
+	for _, args := range [][]string{{"set", "confirmSection.port", "8080"}, {"unset", "confirmSection.port"}} {
+		cmd := NewConfigCommand()
+		cmd.SetIn(strings.NewReader("n\n"))
+		if _, err := executeCommand(cmd, args...); !errors.Is(err, ErrNotConfirmed) {
+			t.Errorf("%s: expected ErrNotConfirmed: %v", args[0], err)
+		}
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "confirmSection:\n  port: 80\n"; string(b) != want {
+		t.Errorf("\ngot:  %q\nwant: %q\n", b, want)
+	}
+
+	cmd := NewConfigCommand()
+	cmd.SetIn(strings.NewReader("y\n"))
+	if _, err := executeCommand(cmd, "set", "confirmSection.port", "8080"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if _, err := executeCommand(NewConfigCommand(), "unset", "confirmSection.port", "--yes"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+type contextKey struct{}
+
+// contextValues holds the bound Structs by type. Cobra can't replace the context of a running command,
+// so the values are stored in a holder added before executing.
+type contextValues struct {
+	sync.Mutex
+	values map[reflect.Type]interface{}
+}
+
+// ToContext stores the resolved Struct in the command's Context, to be retrieved with FromContext.
+// The Config given with UseConfig is stored as well.
+// The command must be executed with ExecuteContext.
+func ToContext(o *BindOptions) { o.toContext = true }
+
+// WithContext returns a context that can hold the Structs bound with ToContext
+func WithContext(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(contextKey{}).(*contextValues); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, &contextValues{values: make(map[reflect.Type]interface{})})
+}
+
+// ExecuteContext executes the command with a context that can hold the Structs bound with ToContext
+func ExecuteContext(ctx context.Context, c *cobra.Command) error {
+	return c.ExecuteContext(WithContext(ctx))
+}
+
+// FromContext returns the Struct of type T that was bound with ToContext
+func FromContext[T any](ctx context.Context) (*T, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	cv, ok := ctx.Value(contextKey{}).(*contextValues)
+	if !ok {
+		return nil, false
+	}
+	cv.Lock()
+	defer cv.Unlock()
+	v, ok := cv.values[reflect.TypeOf((*T)(nil))].(*T)
+	return v, ok
+}
+
+// storeContext stores the Struct pointer and the Config in the context
+func (o *BindOptions) storeContext(ctx context.Context, rawVal interface{}) error {
+	if err := storeContext(ctx, rawVal); err != nil {
+		return err
+	}
+	if o.config != nil {
+		return storeContext(ctx, o.config)
+	}
+	return nil
+}
+
+// storeContext stores the pointer in the context
+func storeContext(ctx context.Context, rawVal interface{}) error {
+	var cv *contextValues
+	if ctx != nil {
+		cv, _ = ctx.Value(contextKey{}).(*contextValues)
+	}
+	if cv == nil {
+		return errors.New("ToContext requires executing the command with cfg.ExecuteContext")
+	}
+	cv.Lock()
+	defer cv.Unlock()
+	cv.values[reflect.TypeOf(rawVal)] = rawVal
+	return nil
+}
@@ -0,0 +1,56 @@
+package cfg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+type contextStruct struct {
+	Name string
+}
+
+func TestFromContext(t *testing.T) {
+
+	var config contextStruct
+	var got *contextStruct
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(cmd *cobra.Command, _ []string) {
+			got, _ = FromContext[contextStruct](cmd.Context())
+		},
+	}
+
+	BindFlags(rootCmd, &config, NoViper, ToContext)
+	rootCmd.SetArgs([]string{"--name", "ctx"})
+
+	if err := ExecuteContext(context.Background(), rootCmd); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got == nil || got.Name != "ctx" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "ctx")
+	}
+
+	if _, ok := FromContext[contextStruct](context.Background()); ok {
+		t.Errorf("Expected no value without a holder")
+	}
+}
+
+func TestToContextRequiresExecuteContext(t *testing.T) {
+
+	var config contextStruct
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, NoViper, ToContext)
+
+	if _, err := executeCommand(rootCmd); err == nil {
+		t.Errorf("Expected an error")
+	}
+}
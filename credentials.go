@@ -0,0 +1,57 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MergeCredentials sets the systemd credentials passed with LoadCredential= or SetCredential=
+// as config values, taking precedence over values that were Set. The file name of a credential
+// is its key, like db.password, and its content without a trailing newline is the value.
+// The keys are redacted by Handler. Credentials are kept in their own layer and never written to the config file.
+// Does nothing when $CREDENTIALS_DIRECTORY is not set, like when not running as a systemd service.
+func MergeCredentials() error {
+	dir := os.Getenv("CREDENTIALS_DIRECTORY")
+	if dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	credentials := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		credentials[strings.ToLower(entry.Name())] = strings.TrimSuffix(string(b), "\n")
+	}
+	loadConfig()
+	mu.Lock()
+	if layers.credentials == nil {
+		layers.credentials = make(map[string]interface{})
+	}
+	for key, value := range credentials {
+		layers.credentials[key] = value
+	}
+	for key := range credentials {
+		syncOverride(strings.SplitN(key, ".", 2)[0])
+	}
+	mu.Unlock()
+	resetCache()
+	schemaKeys.Lock()
+	for key := range credentials {
+		schemaKeys.secrets[key] = true
+	}
+	schemaKeys.Unlock()
+	return nil
+}
@@ -0,0 +1,66 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeCredentials(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "credSection.dsn"), []byte("s3cret\n"), 0400); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("CREDENTIALS_DIRECTORY", dir)
+	Set("credSection.dsn", "from-config")
+
+	if err := MergeCredentials(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := GetString("credSection.dsn"); got != "s3cret" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "s3cret")
+	}
+
+	if !isRedacted("credsection.dsn") {
+		t.Errorf("Expected the credential to be redacted")
+	}
+}
+
+func TestWriteSkipsCredentials(t *testing.T) {
+
+	file := useConfigFile(t, "credWrite:\n  name: File\n")
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "credWrite.password"), []byte("s3cret\n"), 0400); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CREDENTIALS_DIRECTORY", dir)
+
+	if err := MergeCredentials(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	Set("credWrite.name", "Set")
+
+	if err := Write(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), "credwrite:\n  name: Set\n"; got != want {
+		t.Errorf("\ngot:  %q\nwant: %q\n", got, want)
+	}
+
+	if got := GetString("credWrite.password"); got != "s3cret" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "s3cret")
+	}
+}
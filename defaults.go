@@ -0,0 +1,83 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// defaultValues is the defaults layer: the values registered with SetDefault and SetDefaults by lowercase key
+var defaultValues = make(map[string]interface{})
+
+// SetDefault registers the default value of the key.
+// Defaults have the lowest precedence, below the config file, environment, flags and values that are Set.
+func SetDefault(key string, value interface{}) {
+	mu.Lock()
+	setDefault(key, value)
+	mu.Unlock()
+	resetCache()
+}
+
+// setDefault registers the default while holding the write lock
+func setDefault(key string, value interface{}) {
+	viper.SetDefault(key, value)
+	defaultValues[strings.ToLower(key)] = value
+}
+
+// Defaults returns the registered defaults by key
+func Defaults() map[string]interface{} {
+	mu.RLock()
+	defer mu.RUnlock()
+	m := make(map[string]interface{}, len(defaultValues))
+	for k, v := range defaultValues {
+		m[k] = v
+	}
+	return m
+}
+
+// IsDefault reports whether the effective value of the key is its registered default,
+// so dumps can tell defaults apart from configured values
+func IsDefault(key string) bool {
+	loadConfig()
+	mu.RLock()
+	defer mu.RUnlock()
+	def, ok := defaultValues[strings.ToLower(key)]
+	return ok && reflect.DeepEqual(viper.Get(key), def)
+}
+
+// SetDefaults registers the values of the Struct fields as viper defaults,
+// so they show up in AllSettings and IsSet
+func SetDefaults(rawVal interface{}, options ...func(*BindOptions)) {
+	opts := newBindOptions(rawVal, options)
+	mu.Lock()
+	setStructDefaults(opts.key, structValue(rawVal), opts.keyName)
+	mu.Unlock()
+	resetCache()
+}
+
+// SetDefaultsKey registers the values of the Struct fields as viper defaults at the key
+func SetDefaultsKey(key string, rawVal interface{}) {
+	SetDefaults(rawVal, Key(key))
+}
+
+func setStructDefaults(key string, rv reflect.Value, keyName NamingFunc) {
+	for _, f := range structFields(rv.Type()) {
+		if !f.exported {
+			continue
+		}
+		fieldKey := keyName(f.name)
+		if key != "" {
+			fieldKey = key + "." + fieldKey
+		}
+		if f.kind == reflect.Struct {
+			setStructDefaults(fieldKey, rv.Field(f.index), func(name string) string { return name })
+			continue
+		}
+		setDefault(fieldKey, rv.Field(f.index).Interface())
+	}
+}
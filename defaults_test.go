@@ -0,0 +1,67 @@
+package cfg
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+type defaultsStruct struct {
+	Host    string
+	Port    int
+	Verbose bool
+	TLS     struct {
+		Enabled bool
+	}
+}
+
+func TestSetDefaults(t *testing.T) {
+
+	config := defaultsStruct{Host: "localhost", Port: 8080}
+	config.TLS.Enabled = true
+
+	SetDefaultsKey("defaultsSection", &config)
+
+	if got := GetString("defaultsSection.host"); got != "localhost" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "localhost")
+	}
+
+	if got := GetInt("defaultsSection.port"); got != 8080 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, 8080)
+	}
+
+	if !viper.IsSet("defaultsSection.verbose") {
+		t.Errorf("Expected the zero value to be set")
+	}
+
+	if got := Get("defaultsSection.tls.enabled"); got != true {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, true)
+	}
+
+	if _, ok := viper.AllSettings()["defaultssection"]; !ok {
+		t.Errorf("Expected the defaults in AllSettings")
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+
+	SetDefault("defaultLayer.timeout", 30)
+	SetDefault("defaultLayer.retries", 3)
+	Set("defaultLayer.retries", 5)
+
+	if got := GetInt("defaultLayer.timeout"); got != 30 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, 30)
+	}
+
+	if !IsDefault("defaultLayer.timeout") {
+		t.Errorf("Expected timeout to be a default")
+	}
+
+	if IsDefault("defaultLayer.retries") {
+		t.Errorf("Expected retries to be set")
+	}
+
+	if got := Defaults()["defaultlayer.retries"]; got != 3 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, 3)
+	}
+}
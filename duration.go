@@ -0,0 +1,124 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+)
+
+// ExtendedDurations accepts the d (days) and w (weeks) units in durations from the config and flags,
+// like "2d" or "1d12h". A day is always 24 hours.
+var ExtendedDurations = false
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// durationUnits are the units ParseDuration adds to the units of time.ParseDuration
+var durationUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// ParseDuration parses a duration like time.ParseDuration, also accepting the d and w units,
+// like "2d", "1w" or "1d12h30m"
+func ParseDuration(s string) (time.Duration, error) {
+	orig := s
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg, s = s[0] == '-', s[1:]
+	}
+	if s == "0" {
+		return 0, nil
+	}
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+	var d time.Duration
+	for s != "" {
+		i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if i <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		number := s[:i]
+		j := strings.IndexFunc(s[i:], func(r rune) bool { return (r >= '0' && r <= '9') || r == '.' })
+		if j < 0 {
+			j = len(s) - i
+		}
+		unit := s[i : i+j]
+		s = s[i+j:]
+		if size, ok := durationUnits[unit]; ok {
+			f, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", orig)
+			}
+			d += time.Duration(f * float64(size))
+			continue
+		}
+		part, err := time.ParseDuration(number + unit)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		d += part
+	}
+	if neg {
+		return -d, nil
+	}
+	return d, nil
+}
+
+// DurationHookFunc returns a decode hook that converts strings to durations with ParseDuration
+func DurationHookFunc() mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to != durationType {
+			return data, nil
+		}
+		return ParseDuration(data.(string))
+	}
+}
+
+// durationHook converts strings to durations, with the extended units when ExtendedDurations is set
+func durationHook() mapstructure.DecodeHookFunc {
+	if ExtendedDurations {
+		return DurationHookFunc()
+	}
+	return mapstructure.StringToTimeDurationHookFunc()
+}
+
+// decodeHooks is the decoder option Unmarshal and UnmarshalKey add for durations, percentages and secrets
+func decodeHooks(opts []viper.DecoderConfigOption) []viper.DecoderConfigOption {
+	hook := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		durationHook(),
+		percentHook,
+		secretHook,
+		mapstructure.StringToSliceHookFunc(","),
+	))
+	return append([]viper.DecoderConfigOption{hook}, opts...)
+}
+
+// durationValue is a flag value for duration fields, parsing with the extended units when ExtendedDurations is set
+type durationValue time.Duration
+
+func (d *durationValue) Set(s string) error {
+	parse := time.ParseDuration
+	if ExtendedDurations {
+		parse = ParseDuration
+	}
+	v, err := parse(s)
+	if err != nil {
+		return err
+	}
+	*d = durationValue(v)
+	return nil
+}
+
+func (d *durationValue) Type() string { return "duration" }
+
+func (d *durationValue) String() string { return time.Duration(*d).String() }
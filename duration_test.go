@@ -0,0 +1,78 @@
+package cfg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func TestParseDuration(t *testing.T) {
+
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"2d", 48 * time.Hour},
+		{"1w", 168 * time.Hour},
+		{"1d12h", 36 * time.Hour},
+		{"1.5d", 36 * time.Hour},
+		{"-1w2d", -216 * time.Hour},
+		{"90m", 90 * time.Minute},
+		{"0", 0},
+	}
+
+	for _, test := range tests {
+		got, err := ParseDuration(test.in)
+		if err != nil || got != test.want {
+			t.Errorf("ParseDuration(%q)\ngot:  %v, %v\nwant: %v\n", test.in, got, err, test.want)
+		}
+	}
+
+	for _, in := range []string{"", "d", "2", "2x", "1d-2h"} {
+		if _, err := ParseDuration(in); err == nil {
+			t.Errorf("ParseDuration(%q): expected an error", in)
+		}
+	}
+}
+
+type durationStruct struct {
+	Retention time.Duration
+	Timeout   time.Duration
+}
+
+func TestExtendedDurations(t *testing.T) {
+
+	ExtendedDurations = true
+	defer func() { ExtendedDurations = false }()
+
+	viper.Set("durationSection.retention", "2w")
+	viper.Set("durationSection.timeout", "1d")
+
+	var config durationStruct
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, Key("durationSection"))
+
+	if _, err := executeCommand(rootCmd, "--timeout", "1d12h"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if want := (durationStruct{Retention: 336 * time.Hour, Timeout: 36 * time.Hour}); config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+
+	var unmarshalled durationStruct
+	if err := UnmarshalKey("durationSection", &unmarshalled); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if unmarshalled.Retention != 336*time.Hour {
+		t.Errorf("\ngot:  %v\nwant: %v\n", unmarshalled.Retention, 336*time.Hour)
+	}
+}
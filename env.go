@@ -0,0 +1,101 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// envSettings mirrors the environment settings made through cfg, which viper doesn't expose,
+// so Resolutions can tell which variable a key is read from. Guarded by mu.
+var envSettings struct {
+	prefix    string
+	replacer  *strings.Replacer
+	automatic bool
+	bound     map[string]string
+}
+
+// SetEnvPrefix sets the prefix of the environment variables like viper.SetEnvPrefix
+func SetEnvPrefix(prefix string) {
+	mu.Lock()
+	defer mu.Unlock()
+	viper.SetEnvPrefix(prefix)
+	if prefix != "" {
+		envSettings.prefix = prefix
+	}
+}
+
+// SetEnvKeyReplacer sets the replacer of the environment variable names like viper.SetEnvKeyReplacer,
+// for example strings.NewReplacer(".", "_") to read db.host from DB_HOST
+func SetEnvKeyReplacer(r *strings.Replacer) {
+	mu.Lock()
+	defer mu.Unlock()
+	viper.SetEnvKeyReplacer(r)
+	envSettings.replacer = r
+}
+
+// AutomaticEnv reads all keys from the matching environment variables like viper.AutomaticEnv
+func AutomaticEnv() {
+	mu.Lock()
+	defer mu.Unlock()
+	viper.AutomaticEnv()
+	envSettings.automatic = true
+}
+
+// BindEnv binds the key to the environment variable like viper.BindEnv.
+// Without a variable name, the prefixed uppercase key is used.
+func BindEnv(input ...string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if err := viper.BindEnv(input...); err != nil {
+		return err
+	}
+	key := strings.ToLower(input[0])
+	name := envName(key)
+	if len(input) > 1 {
+		name = input[1]
+	}
+	if envSettings.bound == nil {
+		envSettings.bound = make(map[string]string)
+	}
+	envSettings.bound[key] = name
+	return nil
+}
+
+// envName returns the prefixed uppercase name of the key. The caller must hold the lock.
+func envName(key string) string {
+	if envSettings.prefix != "" {
+		return strings.ToUpper(envSettings.prefix + "_" + key)
+	}
+	return strings.ToUpper(key)
+}
+
+// lookupEnv returns the value viper reads for the key from the environment. The caller must hold the lock.
+func lookupEnv(key string) (string, bool) {
+	var names []string
+	if envSettings.automatic {
+		names = append(names, envName(key))
+	}
+	if name, ok := envSettings.bound[key]; ok {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		if envSettings.replacer != nil {
+			name = envSettings.replacer.Replace(name)
+		}
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// resetEnv clears the environment settings. The caller must hold the write lock.
+func resetEnv() {
+	envSettings.prefix, envSettings.replacer, envSettings.automatic, envSettings.bound = "", nil, false, nil
+}
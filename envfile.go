@@ -0,0 +1,108 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EnvFiles are the names of project env files, like ".envrc" or ".env.local", that are imported
+// when the config is loaded. The files are searched for from the working directory upwards
+// and the files in the nearest directory that has any are imported, later names overriding earlier ones.
+// Variables that are already set in the environment are kept.
+//
+// The files are parsed, not executed: only lines like KEY=value or export KEY=value are imported,
+// other shell commands are ignored.
+var EnvFiles []string
+
+// importEnvFiles imports the nearest EnvFiles into the environment
+func importEnvFiles() {
+	if len(EnvFiles) == 0 {
+		return
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	for ; ; dir = filepath.Dir(dir) {
+		found := false
+		env := make(map[string]string)
+		for _, name := range EnvFiles {
+			file := filepath.Join(dir, name)
+			f, err := os.Open(file)
+			if err != nil {
+				continue
+			}
+			found = true
+			if err := parseEnv(f, env); err != nil {
+				fmt.Fprintf(WarningOutput, "Warning: %s (%s)\n", err, file)
+			}
+			f.Close()
+		}
+		if found {
+			for k, v := range env {
+				if _, ok := os.LookupEnv(k); !ok {
+					os.Setenv(k, v)
+				}
+			}
+			return
+		}
+		if filepath.Dir(dir) == dir {
+			return
+		}
+	}
+}
+
+// parseEnv parses the assignments of an env file into env.
+// Values may be quoted. Variables in unquoted and double quoted values are expanded.
+func parseEnv(r io.Reader, env map[string]string) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		i := strings.Index(line, "=")
+		if i <= 0 || !isEnvName(line[:i]) {
+			continue
+		}
+		key, value := line[:i], strings.TrimSpace(line[i+1:])
+		lookup := func(name string) string {
+			if v, ok := env[name]; ok {
+				return v
+			}
+			return os.Getenv(name)
+		}
+		switch {
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			value = os.Expand(strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`), lookup)
+		default:
+			if j := strings.Index(value, " #"); j >= 0 {
+				value = strings.TrimSpace(value[:j])
+			}
+			value = os.Expand(value, lookup)
+		}
+		env[key] = value
+	}
+	return scanner.Err()
+}
+
+// isEnvName reports whether the name is a valid environment variable name
+func isEnvName(name string) bool {
+	for i, r := range name {
+		if r != '_' && (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEnv(t *testing.T) {
+
+	os.Setenv("ENVFILE_HOME", "/home/user")
+	defer os.Unsetenv("ENVFILE_HOME")
+
+	content := `# project env
+export API_URL=https://example.com # comment
+DB_NAME="app ${ENVFILE_HOME}"
+RAW='$NOT_EXPANDED'
+CACHE=$ENVFILE_HOME/cache
+URL_WITH_NAME=${DB_NAME}
+use nix
+if [ -f x ]; then echo=1; fi
+`
+	env := make(map[string]string)
+	if err := parseEnv(strings.NewReader(content), env); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"API_URL":       "https://example.com",
+		"DB_NAME":       "app /home/user",
+		"RAW":           "$NOT_EXPANDED",
+		"CACHE":         "/home/user/cache",
+		"URL_WITH_NAME": "app /home/user",
+	}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", env, want)
+	}
+}
+
+func TestImportEnvFiles(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	nested := filepath.Join(dir, "project", "src")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "project", ".envrc"), []byte("export ENVRC_VALUE=envrc\nENVRC_OVERRIDE=envrc\nENVRC_SET=envrc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "project", ".env.local"), []byte("ENVRC_OVERRIDE=local\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(nested); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("ENVRC_SET", "environment")
+	defer func() {
+		for _, k := range []string{"ENVRC_VALUE", "ENVRC_OVERRIDE", "ENVRC_SET"} {
+			os.Unsetenv(k)
+		}
+	}()
+
+	EnvFiles = []string{".envrc", ".env.local"}
+	defer func() { EnvFiles = nil }()
+	importEnvFiles()
+
+	for k, want := range map[string]string{"ENVRC_VALUE": "envrc", "ENVRC_OVERRIDE": "local", "ENVRC_SET": "environment"} {
+		if got := os.Getenv(k); got != want {
+			t.Errorf("%s\ngot:  %v\nwant: %v\n", k, got, want)
+		}
+	}
+}
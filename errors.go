@@ -0,0 +1,110 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ErrConfigNotFound is returned when there is no config file to read or write
+var ErrConfigNotFound = errors.New("config file not found")
+
+// ErrDecode is returned when the config can't be decoded into a Struct
+type ErrDecode struct {
+	// Key is the config key that was decoded, empty for the whole config
+	Key string
+	// Type is the type decoded into
+	Type string
+	// Value is the config value
+	Value interface{}
+	Err   error
+}
+
+func (e *ErrDecode) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("decoding config into %s: %v", e.Type, e.Err)
+	}
+	return fmt.Sprintf("decoding config key %q into %s: %v", e.Key, e.Type, e.Err)
+}
+
+func (e *ErrDecode) Unwrap() error { return e.Err }
+
+// ErrValidation is returned when a field value doesn't satisfy a rule, like a `required:"true"` tag
+type ErrValidation struct {
+	// Field is the Struct field name
+	Field string
+	// Flag is the flag name of the field
+	Flag string
+	// Arg is the name of the positional argument of the field
+	Arg string
+	// Key is the config key of the field when validating the config
+	Key string
+	// Rule is the rule that failed, like "required"
+	Rule string
+}
+
+func (e *ErrValidation) Error() string {
+	if e.Key != "" {
+		return fmt.Sprintf("invalid value for %s: %s", e.Key, e.Rule)
+	}
+	if e.Arg != "" {
+		if e.Rule == "required" {
+			return fmt.Sprintf("missing required argument <%s>", e.Arg)
+		}
+		return fmt.Sprintf("invalid argument <%s>: %s", e.Arg, e.Rule)
+	}
+	if e.Rule == "required" {
+		return fmt.Sprintf("missing required value --%s", e.Flag)
+	}
+	return fmt.Sprintf("invalid value --%s: %s", e.Flag, e.Rule)
+}
+
+// ErrUnknownKey is returned when the config has a key that no field reads, like with StrictKeys
+type ErrUnknownKey struct {
+	Key string
+	// Suggestion is the nearest known key, empty when no key is close
+	Suggestion string
+}
+
+func (e *ErrUnknownKey) Error() string {
+	if e.Suggestion == "" {
+		return fmt.Sprintf("unknown config key %q", e.Key)
+	}
+	return fmt.Sprintf("unknown config key %q, did you mean %q?", e.Key, e.Suggestion)
+}
+
+// ErrItemNotFound is returned when a collection has no item with the id
+type ErrItemNotFound struct {
+	Collection string
+	// ID is the requested id, empty when no item was selected
+	ID string
+	// Available lists the ids in the collection when known
+	Available []string
+}
+
+func (e *ErrItemNotFound) Error() string {
+	msg := fmt.Sprintf("%s: item %q not found", e.Collection, e.ID)
+	if e.ID == "" {
+		msg = fmt.Sprintf("%s: no item selected", e.Collection)
+	}
+	if e.Available != nil {
+		msg += fmt.Sprintf(" (available: %s)", strings.Join(e.Available, ", "))
+	}
+	return msg
+}
+
+// configError wraps errors about a missing config file with ErrConfigNotFound
+func configError(err error) error {
+	var notFound viper.ConfigFileNotFoundError
+	if errors.As(err, &notFound) || errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %v", ErrConfigNotFound, err)
+	}
+	return err
+}
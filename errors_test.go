@@ -0,0 +1,80 @@
+package cfg
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+func TestErrDecode(t *testing.T) {
+
+	Set("errDecodeSection", map[string]interface{}{"port": "8080x"})
+
+	var config strictStruct
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	CreateFlags(flags, &config)
+
+	err := Resolve(flags, &config, Key("errDecodeSection"), StrictTypes)
+
+	var decodeErr *ErrDecode
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("Expected an ErrDecode: %v", err)
+	}
+	if decodeErr.Key != "errDecodeSection" || decodeErr.Type != "cfg.strictStruct" {
+		t.Errorf("\ngot:  %v %v\nwant: %v %v\n", decodeErr.Key, decodeErr.Type, "errDecodeSection", "cfg.strictStruct")
+	}
+}
+
+func TestErrConfigNotFound(t *testing.T) {
+
+	Reset()
+	defer Reset()
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	viper.SetConfigFile(filepath.Join(dir, "missing.yaml"))
+
+	if err := Reload(); !errors.Is(err, ErrConfigNotFound) {
+		t.Errorf("Expected ErrConfigNotFound: %v", err)
+	}
+}
+
+func TestErrItemNotFound(t *testing.T) {
+
+	Set("errItems", []map[string]interface{}{{"name": "prod"}})
+
+	err := RemoveCollectionItem("errItems", "name", "dev")
+
+	var notFound *ErrItemNotFound
+	if !errors.As(err, &notFound) {
+		t.Fatalf("Expected an ErrItemNotFound: %v", err)
+	}
+	if notFound.Collection != "errItems" || notFound.ID != "dev" {
+		t.Errorf("\ngot:  %v %v\nwant: %v %v\n", notFound.Collection, notFound.ID, "errItems", "dev")
+	}
+}
+
+func TestErrValidation(t *testing.T) {
+
+	var config requiredStruct
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	CreateFlags(flags, &config)
+
+	err := Resolve(flags, &config, NoViper)
+
+	var validationErr *ErrValidation
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected an ErrValidation: %v", err)
+	}
+	if validationErr.Field != "User" || validationErr.Rule != "required" {
+		t.Errorf("\ngot:  %v %v\nwant: %v %v\n", validationErr.Field, validationErr.Rule, "User", "required")
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ExecCommands are the commands that config values like "!exec op read op://vault/item/password" may run.
+// Names match the command as written or its base name. Without commands, values are not executed.
+// In YAML files the value can be quoted, like key: '!exec op read ...', or written as the !exec tag,
+// like key: !exec op read ..., which is read as the quoted value.
+var ExecCommands []string
+
+// ExecTimeout is how long the command of an !exec value may run
+var ExecTimeout = 30 * time.Second
+
+// execPrefix starts config values that are the output of a command
+const execPrefix = "!exec "
+
+// execTag matches YAML values written as the !exec tag with their trailing comment,
+// which YAML parsers read as the untagged command
+var execTag = regexp.MustCompile(`(?m)^([^#'"\n]*?(?::|-)[ \t]+)!exec[ \t]+(\S[^\n]*?)(?:[ \t]+#[^\n]*)?[ \t]*$`)
+
+// quoteExecTags replaces the !exec tags in the YAML with quoted !exec values
+func quoteExecTags(b []byte) []byte {
+	return execTag.ReplaceAllFunc(b, func(m []byte) []byte {
+		sub := execTag.FindSubmatch(m)
+		return []byte(string(sub[1]) + strconv.Quote(execPrefix+string(sub[2])))
+	})
+}
+
+// hasExecTags reports whether the YAML file has values written as the !exec tag
+func hasExecTags(file string) bool {
+	if t := configType(file); t != "yaml" && t != "yml" {
+		return false
+	}
+	b, err := os.ReadFile(file)
+	return err == nil && execTag.Match(b)
+}
+
+// execRef is a config value that was replaced by the output of its command
+type execRef struct {
+	ref   string
+	value string
+}
+
+// execRefs holds the !exec values that were resolved, by key. Guarded by mu.
+var execRefs map[string]execRef
+
+// resolveExecValues replaces the !exec values in the config with the output of their commands,
+// except for the keys of Secret fields, which run the command when resolved.
+// The command is split on whitespace and run without a shell. Failures are warnings and keep the value.
+func resolveExecValues() {
+	if len(ExecCommands) == 0 {
+		return
+	}
+	flat := make(map[string]interface{})
+	mu.RLock()
+	flattenSettings(configSettings(), "", flat)
+	mu.RUnlock()
+	resolved := make(map[string]execRef)
+	for key, v := range flat {
+		ref, ok := v.(string)
+		if !ok || !strings.HasPrefix(ref, execPrefix) {
+			continue
+		}
+		if lazySecretKey(key) {
+			continue
+		}
+		out, err := runExec(context.Background(), strings.TrimPrefix(ref, execPrefix))
+		if err != nil {
+			fmt.Fprintf(WarningOutput, "Warning: %s: %s\n", key, err)
+			continue
+		}
+		resolved[key] = execRef{ref: ref, value: out}
+	}
+	if len(resolved) == 0 {
+		return
+	}
+	mu.Lock()
+	defer resetCache()
+	defer mu.Unlock()
+	if execRefs == nil {
+		execRefs = make(map[string]execRef)
+	}
+	for key, r := range resolved {
+		execRefs[key] = r
+	}
+	if err := rebuildConfig(); err != nil {
+		fmt.Fprintf(WarningOutput, "Warning: %s (%s)\n", err, viper.ConfigFileUsed())
+	}
+}
+
+// runExec runs the allowed command and returns its output without the trailing newline
+func runExec(ctx context.Context, command string) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("empty !exec command")
+	}
+	allowed := false
+	for _, name := range ExecCommands {
+		allowed = allowed || name == args[0] || name == filepath.Base(args[0])
+	}
+	if !allowed {
+		return "", fmt.Errorf("command %s is not allowed in !exec values", args[0])
+	}
+	ctx, cancel := context.WithTimeout(ctx, ExecTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", args[0], err)
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
@@ -0,0 +1,91 @@
+package cfg
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestExecValues(t *testing.T) {
+
+	Reset()
+	defer Reset()
+
+	ExecCommands = []string{"echo"}
+	defer func() { ExecCommands = nil }()
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	content := "execSection:\n  password: '!exec echo s3cret'\n  denied: '!exec cat /etc/hostname'\n"
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ReadInConfig()
+	viper.SetConfigFile(file)
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := GetString("execSection.password"); got != "s3cret" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "s3cret")
+	}
+
+	if got := GetString("execSection.denied"); got != "!exec cat /etc/hostname" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "!exec cat /etc/hostname")
+	}
+
+	Set("execSection.name", "written")
+	if err := Write(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(b), "s3cret\n") || !strings.Contains(string(b), "!exec echo s3cret") {
+		t.Errorf("Expected the !exec reference to be written:\n%s", b)
+	}
+}
+
+func TestExecTag(t *testing.T) {
+
+	ExecCommands = []string{"echo"}
+	defer func() { ExecCommands = nil }()
+
+	file := useConfigFile(t, "execTag:\n  password: !exec echo s3cret # from the vault\n  hosts:\n    - !exec echo host\n  note: 'a !exec b'\n")
+
+	if got := GetString("execTag.password"); got != "s3cret" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "s3cret")
+	}
+
+	if got := fmt.Sprint(Get("execTag.hosts")); got != "[!exec echo host]" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "[!exec echo host]")
+	}
+
+	if got := GetString("execTag.note"); got != "a !exec b" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "a !exec b")
+	}
+
+	if err := Write(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "password: '!exec echo s3cret'\n") {
+		t.Errorf("Expected the !exec reference to be written:\n%s", b)
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"expvar"
+)
+
+// ExpvarName is the name of the variable published by PublishExpvar
+var ExpvarName = "config"
+
+// PublishExpvar publishes the redacted config and the config file path under ExpvarName,
+// evaluated each time the variables are read. Publishing more than once has no effect.
+func PublishExpvar() {
+	if expvar.Get(ExpvarName) != nil {
+		return
+	}
+	expvar.Publish(ExpvarName, expvar.Func(func() interface{} {
+		report := effectiveConfig()
+		return map[string]interface{}{
+			"config": report.Config,
+			"file":   report.File,
+		}
+	}))
+}
@@ -0,0 +1,30 @@
+package cfg
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+)
+
+func TestPublishExpvar(t *testing.T) {
+
+	Set("expvarSection", map[string]interface{}{"name": "Visible", "token": "abc"})
+	PublishExpvar()
+	PublishExpvar()
+
+	var got struct {
+		Config map[string]interface{} `json:"config"`
+		File   string                 `json:"file"`
+	}
+	if err := json.Unmarshal([]byte(expvar.Get(ExpvarName).String()), &got); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	section, _ := got.Config["expvarsection"].(map[string]interface{})
+	if name := section["name"]; name != "Visible" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", name, "Visible")
+	}
+	if token := section["token"]; token != Redacted {
+		t.Errorf("\ngot:  %v\nwant: %v\n", token, Redacted)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/iancoleman/strcase"
+	"github.com/spf13/pflag"
+)
+
+// FeaturesKey is the config key holding the feature flags, like features.search: true
+var FeaturesKey = "features"
+
+// FeatureSet resolves feature flags from flags, environment variables, the config and defaults, in that order
+type FeatureSet struct {
+	mu        sync.Mutex
+	defaults  map[string]bool
+	enable    []string
+	disable   []string
+	listeners []func(name string, enabled bool)
+	states    map[string]bool
+}
+
+// Features are the feature flags of the application
+var Features = &FeatureSet{defaults: make(map[string]bool)}
+
+func init() {
+	onReload(Features.notify)
+}
+
+// Register registers a feature with its default state
+func (f *FeatureSet) Register(name string, enabled bool) {
+	f.mu.Lock()
+	f.defaults[strings.ToLower(name)] = enabled
+	f.mu.Unlock()
+	SetDefault(FeaturesKey+"."+name, enabled)
+}
+
+// AddFlags adds the --enable-feature and --disable-feature flags that override features by name
+func (f *FeatureSet) AddFlags(flags *pflag.FlagSet) {
+	flags.StringSliceVar(&f.enable, "enable-feature", nil, "Enable the features")
+	flags.StringSliceVar(&f.disable, "disable-feature", nil, "Disable the features")
+}
+
+// IsEnabled reports whether the feature is enabled.
+// The feature can be overridden with the flags and with a FEATURE_<NAME> environment variable.
+func (f *FeatureSet) IsEnabled(name string) bool {
+	f.mu.Lock()
+	enable, disable := f.enable, f.disable
+	def := f.defaults[strings.ToLower(name)]
+	f.mu.Unlock()
+	for _, n := range disable {
+		if strings.EqualFold(n, name) {
+			return false
+		}
+	}
+	for _, n := range enable {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	if env, ok := os.LookupEnv("FEATURE_" + strcase.ToScreamingSnake(name)); ok {
+		if enabled, err := strconv.ParseBool(env); err == nil {
+			return enabled
+		}
+	}
+	return GetOr(FeaturesKey+"."+name, def)
+}
+
+// Enabled returns the names of the enabled features
+func (f *FeatureSet) Enabled() []string {
+	var names []string
+	for name, enabled := range f.all() {
+		if enabled {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OnChange registers a function that is called for every feature that changes state when the config is reloaded
+func (f *FeatureSet) OnChange(fn func(name string, enabled bool)) {
+	states := f.all()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.listeners) == 0 {
+		f.states = states
+	}
+	f.listeners = append(f.listeners, fn)
+}
+
+// all returns the state of the registered and configured features
+func (f *FeatureSet) all() map[string]bool {
+	names := make(map[string]bool)
+	f.mu.Lock()
+	for name := range f.defaults {
+		names[name] = true
+	}
+	f.mu.Unlock()
+	loadConfig()
+	mu.RLock()
+	for name := range sectionSettings(FeaturesKey) {
+		names[name] = true
+	}
+	mu.RUnlock()
+	states := make(map[string]bool, len(names))
+	for name := range names {
+		states[name] = f.IsEnabled(name)
+	}
+	return states
+}
+
+// notify calls the listeners for the features that changed state
+func (f *FeatureSet) notify() {
+	f.mu.Lock()
+	listeners, prev := f.listeners, f.states
+	f.mu.Unlock()
+	if len(listeners) == 0 {
+		return
+	}
+	states := f.all()
+	f.mu.Lock()
+	f.states = states
+	f.mu.Unlock()
+	var changed []string
+	for name, enabled := range states {
+		if enabled != prev[name] {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	for _, name := range changed {
+		for _, fn := range listeners {
+			fn(name, states[name])
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+func TestFeatures(t *testing.T) {
+
+	Features.Register("featureDefaultOn", true)
+	Features.Register("featureDefaultOff", false)
+	Set(FeaturesKey+".featureConfigured", true)
+
+	if !Features.IsEnabled("featureDefaultOn") || Features.IsEnabled("featureDefaultOff") {
+		t.Errorf("Unexpected defaults")
+	}
+
+	if !Features.IsEnabled("featureConfigured") {
+		t.Errorf("Expected the configured feature to be enabled")
+	}
+
+	os.Setenv("FEATURE_FEATURE_DEFAULT_OFF", "true")
+	defer os.Unsetenv("FEATURE_FEATURE_DEFAULT_OFF")
+
+	if !Features.IsEnabled("featureDefaultOff") {
+		t.Errorf("Expected the environment to enable the feature")
+	}
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	Features.AddFlags(flags)
+	defer func() { Features.enable, Features.disable = nil, nil }()
+
+	if err := flags.Parse([]string{"--disable-feature", "featureDefaultOn,featureDefaultOff"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if Features.IsEnabled("featureDefaultOn") || Features.IsEnabled("featureDefaultOff") {
+		t.Errorf("Expected the flag to disable the features")
+	}
+}
+
+func TestFeaturesOnChange(t *testing.T) {
+
+	Reset()
+	defer Reset()
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(file, []byte("features:\n  reloaded: false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	viper.SetConfigFile(file)
+	if err := Reload(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	changed := make(map[string]bool)
+	Features.OnChange(func(name string, enabled bool) {
+		changed[name] = enabled
+	})
+	defer func() { Features.listeners = nil }()
+
+	if err := ioutil.WriteFile(file, []byte("features:\n  reloaded: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Reload(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if want := map[string]bool{"reloaded": true}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", changed, want)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fieldInfo holds the reflection metadata and tags of a Struct field
+type fieldInfo struct {
+	index    int
+	name     string
+	kind     reflect.Kind
+	typ      reflect.Type
+	offset   uintptr
+	exported bool
+	usage    string
+	choices  []string
+	secret   bool
+	required bool
+	complete string
+	// arg is the name of the positional argument, argIndex its position or -1 for flags
+	arg      string
+	argIndex int
+	// variadic fields take the remaining positional arguments
+	variadic bool
+	// path is "file" or "dir" for fields with a path tag, pathExists checks that the path exists
+	// and pathCreate creates the directory
+	path       string
+	pathExists bool
+	pathCreate bool
+	// addr checks that the value is a host:port address, optionally with a scheme when addrScheme is set
+	addr       bool
+	addrScheme bool
+}
+
+// fieldCache holds the []fieldInfo of each Struct type
+var fieldCache sync.Map
+
+// structFields returns the field metadata of a Struct type, reflecting over the type only once
+func structFields(rt reflect.Type) []fieldInfo {
+	if fields, ok := fieldCache.Load(rt); ok {
+		return fields.([]fieldInfo)
+	}
+	fields := make([]fieldInfo, rt.NumField())
+	args := 0
+	for i := range fields {
+		ft := rt.Field(i)
+		fields[i] = fieldInfo{
+			index:    i,
+			name:     ft.Name,
+			kind:     ft.Type.Kind(),
+			typ:      ft.Type,
+			offset:   ft.Offset,
+			exported: ft.PkgPath == "",
+			usage:    ft.Tag.Get("usage"),
+			secret:   ft.Tag.Get("secret") == "true" || ft.Type == secretType,
+			required: ft.Tag.Get("required") == "true",
+			complete: ft.Tag.Get("complete"),
+			argIndex: -1,
+		}
+		if tag, ok := ft.Tag.Lookup("arg"); ok {
+			var variadic bool
+			fields[i].arg, fields[i].argIndex, variadic = parseArgTag(tag, args)
+			fields[i].variadic = variadic && ft.Type.Kind() == reflect.Slice
+			args++
+		}
+		if tag := ft.Tag.Get("path"); tag != "" && ft.Type.Kind() == reflect.String {
+			options := strings.Split(tag, ",")
+			fields[i].path = options[0]
+			for _, option := range options[1:] {
+				fields[i].pathExists = fields[i].pathExists || option == "exists"
+				fields[i].pathCreate = fields[i].pathCreate || option == "create"
+			}
+		}
+		if tag := ft.Tag.Get("addr"); tag != "" && ft.Type.Kind() == reflect.String {
+			fields[i].addr = true
+			fields[i].addrScheme = tag == "scheme"
+		}
+		if tag := ft.Tag.Get("choices"); tag != "" {
+			fields[i].choices = strings.Split(tag, ",")
+		}
+	}
+	actual, _ := fieldCache.LoadOrStore(rt, fields)
+	return actual.([]fieldInfo)
+}
+
+// parseArgTag returns the name and position of an `arg:"0"` or `arg:"name"` tag.
+// Named arguments take the position of the tag among the arg tags.
+// A slice field tagged `arg:"name..."` takes the remaining arguments.
+func parseArgTag(tag string, position int) (name string, index int, variadic bool) {
+	if strings.HasSuffix(tag, "...") {
+		tag, variadic = strings.TrimSuffix(tag, "..."), true
+	}
+	if i, err := strconv.Atoi(tag); err == nil {
+		return "", i, variadic
+	}
+	return tag, position, variadic
+}
+
+// structValue returns the Struct value a pointer points to
+func structValue(rawVal interface{}) reflect.Value {
+	rvp := reflect.ValueOf(rawVal) // pointer struct value
+	if k := rvp.Kind(); k != reflect.Ptr {
+		panic("Value is not a pointer")
+	}
+	rv := rvp.Elem() // struct value from pointer
+	if k := rv.Kind(); k != reflect.Struct {
+		panic("Value is not a struct")
+	}
+	return rv
+}
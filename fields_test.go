@@ -0,0 +1,43 @@
+package cfg
+
+import (
+	"reflect"
+	"testing"
+)
+
+type taggedStruct struct {
+	Name     string `usage:"The name" required:"true"`
+	Password string `secret:"true"`
+	Mode     string `choices:"fast,slow"`
+	internal int
+}
+
+func TestStructFields(t *testing.T) {
+
+	rt := reflect.TypeOf(taggedStruct{})
+	fields := structFields(rt)
+
+	if len(fields) != 4 {
+		t.Fatalf("\ngot:  %v\nwant: %v\n", len(fields), 4)
+	}
+
+	if f := fields[0]; f.name != "Name" || f.usage != "The name" || !f.required || f.kind != reflect.String {
+		t.Errorf("Unexpected field: %+v", f)
+	}
+
+	if f := fields[1]; !f.secret {
+		t.Errorf("Unexpected field: %+v", f)
+	}
+
+	if f := fields[2]; !reflect.DeepEqual(f.choices, []string{"fast", "slow"}) {
+		t.Errorf("Unexpected field: %+v", f)
+	}
+
+	if f := fields[3]; f.exported {
+		t.Errorf("Unexpected field: %+v", f)
+	}
+
+	if again := structFields(rt); &again[0] != &fields[0] {
+		t.Errorf("Expected the cached fields")
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// FormatConfig rewrites a yaml or json config document in a canonical style: keys of bound Structs
+// in the order of their fields, other keys sorted, two space indentation and normalized scalars.
+// Comments are not kept.
+func FormatConfig(b []byte, format string) ([]byte, error) {
+	var doc interface{}
+	var err error
+	switch format {
+	case "yaml", "yml":
+		err = yaml.Unmarshal(b, &doc)
+	case "json":
+		err = json.Unmarshal(b, &doc)
+	default:
+		return nil, fmt.Errorf("can't format %s config", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ordered := canonical(doc, "")
+	if format == "json" {
+		var buf bytes.Buffer
+		if err := writeOrderedJSON(&buf, ordered, ""); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\n")
+		return buf.Bytes(), nil
+	}
+	if doc == nil {
+		return nil, nil
+	}
+	return yaml.Marshal(ordered)
+}
+
+// canonical converts the maps to yaml.MapSlice ordered by the schema of the bound Structs at the path
+func canonical(v interface{}, path string) interface{} {
+	if m, ok := toStringMap(v); ok {
+		rank := schemaOrder(path)
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.SliceStable(keys, func(i, j int) bool {
+			ri, iok := rank[strings.ToLower(keys[i])]
+			rj, jok := rank[strings.ToLower(keys[j])]
+			switch {
+			case iok && jok:
+				return ri < rj
+			case iok != jok:
+				return iok
+			}
+			return keys[i] < keys[j]
+		})
+		ordered := make(yaml.MapSlice, 0, len(keys))
+		for _, k := range keys {
+			child := strings.ToLower(k)
+			if path != "" {
+				child = path + "." + child
+			}
+			ordered = append(ordered, yaml.MapItem{Key: k, Value: canonical(m[k], child)})
+		}
+		return ordered
+	}
+	if list, ok := v.([]interface{}); ok {
+		for i := range list {
+			list[i] = canonical(list[i], path)
+		}
+	}
+	return v
+}
+
+// schemaOrder returns the position of the field keys of the Structs bound at the lowercase path
+func schemaOrder(path string) map[string]int {
+	schemaKeys.Lock()
+	defer schemaKeys.Unlock()
+	rank := make(map[string]int)
+	for _, b := range schemaKeys.bindings {
+		if b.key != path {
+			continue
+		}
+		for _, f := range structFields(b.rt) {
+			key := strings.ToLower(b.keyName(f.name))
+			if _, ok := rank[key]; !ok && f.exported {
+				rank[key] = len(rank)
+			}
+		}
+	}
+	return rank
+}
+
+// writeOrderedJSON writes the value as indented json, keeping the order of yaml.MapSlice keys
+func writeOrderedJSON(buf *bytes.Buffer, v interface{}, indent string) error {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		if len(v) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{\n")
+		for i, item := range v {
+			key, _ := json.Marshal(fmt.Sprintf("%v", item.Key))
+			buf.WriteString(indent + "  ")
+			buf.Write(key)
+			buf.WriteString(": ")
+			if err := writeOrderedJSON(buf, item.Value, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "}")
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for i, item := range v {
+			buf.WriteString(indent + "  ")
+			if err := writeOrderedJSON(buf, item, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "]")
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+	}
+	return nil
+}
+
+// newFmtCommand creates the config fmt command formatting config files
+func newFmtCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "fmt [file...]",
+		Short: "Format config files",
+		Long: "Rewrite config files in a canonical style: keys in the order of the bound Structs, other keys sorted, " +
+			"normalized indentation and scalars. Formats the config file in use when no files are given. Comments are not kept.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			check, _ := cmd.Flags().GetBool("check")
+			files := args
+			if len(files) == 0 {
+				file := configFile()
+				if file == "" {
+					return ErrConfigNotFound
+				}
+				files = []string{file}
+			}
+			var unformatted []string
+			for _, file := range files {
+				b, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				formatted, err := FormatConfig(b, strings.TrimPrefix(filepath.Ext(file), "."))
+				if err != nil {
+					return fmt.Errorf("%s: %w", file, err)
+				}
+				if bytes.Equal(b, formatted) {
+					continue
+				}
+				if check {
+					fmt.Fprintln(cmd.OutOrStdout(), file)
+					unformatted = append(unformatted, file)
+					continue
+				}
+				if err := os.WriteFile(file, formatted, 0600); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Formatted %s\n", file)
+			}
+			if len(unformatted) > 0 {
+				return fmt.Errorf("%d config files are not formatted", len(unformatted))
+			}
+			return nil
+		},
+	}
+	c.Flags().Bool("check", false, "List the files that are not formatted instead of rewriting them and fail when there are any")
+	return c
+}
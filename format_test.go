@@ -0,0 +1,76 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+type formatStruct struct {
+	Zone    string
+	Address string
+}
+
+func TestFormatConfig(t *testing.T) {
+
+	BindFlags(&cobra.Command{Use: "root"}, &formatStruct{}, Key("fmtSection"))
+
+	content := "other: 1\nfmtSection:\n    extra: yes\n    address: 'localhost'\n    zone: \"eu\"\nalpha:\n    - b\n    - a\n"
+	want := "alpha:\n- b\n- a\nfmtSection:\n  zone: eu\n  address: localhost\n  extra: true\nother: 1\n"
+
+	got, err := FormatConfig([]byte(content), "yaml")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if string(got) != want {
+		t.Errorf("\ngot:  %q\nwant: %q\n", got, want)
+	}
+
+	got, err = FormatConfig([]byte(`{"other":1,"fmtSection":{"address":"localhost","zone":"eu"},"list":[]}`), "json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want = "{\n  \"fmtSection\": {\n    \"zone\": \"eu\",\n    \"address\": \"localhost\"\n  },\n  \"list\": [],\n  \"other\": 1\n}\n"
+	if string(got) != want {
+		t.Errorf("\ngot:  %q\nwant: %q\n", got, want)
+	}
+}
+
+func TestConfigFmtCommand(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(file, []byte("b: 1\na:   2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := executeCommand(NewConfigCommand(), "fmt", "--check", file); err == nil {
+		t.Errorf("Expected an error for an unformatted file")
+	}
+
+	if _, err := executeCommand(NewConfigCommand(), "fmt", file); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := executeCommand(NewConfigCommand(), "fmt", "--check", file); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != "a: 2\nb: 1\n" {
+		t.Errorf("\ngot:  %q\nwant: %q\n", b, "a: 2\nb: 1\n")
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// RedactKeys are patterns of keys whose values are hidden in the Handler output, matched with path.Match
+// against the lowercase key, like servers[0].password, and against its last element, like password.
+// Fields of bound Structs with a `secret:"true"` tag are always hidden.
+var RedactKeys = []string{"*password*", "*secret*", "*token*", "*apikey*"}
+
+// Redacted replaces hidden values
+const Redacted = "[redacted]"
+
+// Handler returns an http.Handler serving the effective config as JSON with secrets redacted,
+// the source of each key and the status of the last reload.
+// A POST to a path ending in /reload reloads the config.
+//
+//	http.Handle("/debug/config/", cfg.Handler())
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/reload") {
+			if r.Method != http.MethodPost {
+				w.Header().Set("Allow", http.MethodPost)
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			err := Reload()
+			status := http.StatusOK
+			if err != nil {
+				status = http.StatusInternalServerError
+			}
+			writeJSON(w, status, LastReload())
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, effectiveConfig())
+	})
+}
+
+type configReport struct {
+	Config  map[string]interface{} `json:"config"`
+	Sources map[string]string      `json:"sources"`
+	File    string                 `json:"file,omitempty"`
+	Reload  ReloadStatus           `json:"reload"`
+}
+
+// effectiveConfig returns the redacted config with the source of each key as Resolutions finds it
+func effectiveConfig() configReport {
+	report := configReport{Config: make(map[string]interface{}), Sources: make(map[string]string), Reload: LastReload()}
+	for _, r := range Resolutions() {
+		setPath(report.Config, strings.Split(r.Key, "."), r.Value)
+		report.Sources[r.Key] = r.Source
+	}
+	mu.RLock()
+	report.File = viper.ConfigFileUsed()
+	mu.RUnlock()
+	return report
+}
+
+// redact returns the value with the values of hidden keys replaced,
+// looking into the maps and lists it holds
+func redact(key string, value interface{}) interface{} {
+	if isRedacted(key) {
+		return Redacted
+	}
+	if m, ok := toStringMap(value); ok {
+		redacted := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			redacted[k] = redact(key+"."+strings.ToLower(k), v)
+		}
+		return redacted
+	}
+	if list, ok := value.([]interface{}); ok {
+		redacted := make([]interface{}, len(list))
+		for i, v := range list {
+			redacted[i] = redact(fmt.Sprintf("%s[%d]", key, i), v)
+		}
+		return redacted
+	}
+	return value
+}
+
+// listIndex matches the list indexes in keys like servers[0].password
+var listIndex = regexp.MustCompile(`\[\d+\]`)
+
+// isRedacted reports whether the value of the key is hidden
+func isRedacted(key string) bool {
+	schemaKeys.Lock()
+	secret := schemaKeys.secrets[key] || schemaKeys.secrets[listIndex.ReplaceAllString(key, "")]
+	schemaKeys.Unlock()
+	if secret {
+		return true
+	}
+	leaf := key[strings.LastIndex(key, ".")+1:]
+	for _, pattern := range RedactKeys {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, leaf); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// setPath sets the value in the nested maps
+func setPath(m map[string]interface{}, path []string, value interface{}) {
+	for _, p := range path[:len(path)-1] {
+		next, ok := m[p].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			m[p] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = value
+}
+
+// jsonEqual reports whether the values have the same JSON encoding
+func jsonEqual(a interface{}, b interface{}) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
@@ -0,0 +1,108 @@
+package cfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+
+	Set("handlerSection", map[string]interface{}{"name": "Visible", "password": "hunter2"})
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("\ngot:  %v\nwant: %v\n", rec.Code, http.StatusOK)
+	}
+
+	var report configReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	section, _ := report.Config["handlersection"].(map[string]interface{})
+	if got := section["name"]; got != "Visible" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "Visible")
+	}
+	if got := section["password"]; got != Redacted {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, Redacted)
+	}
+	if got := report.Sources["firstparam"]; got != "file" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "file")
+	}
+	if got := report.Sources["handlersection.name"]; got != "runtime" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "runtime")
+	}
+}
+
+func TestHandlerSources(t *testing.T) {
+
+	t.Cleanup(Reset)
+	AutomaticEnv()
+	SetDefault("handlerEnv", "Default")
+	t.Setenv("HANDLERENV", "Env")
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+
+	var report configReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := report.Sources["handlerenv"]; got != "env" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "env")
+	}
+	for _, r := range Resolutions() {
+		if got := report.Sources[r.Key]; got != r.Source {
+			t.Errorf("%s\ngot:  %v\nwant: %v\n", r.Key, got, r.Source)
+		}
+	}
+}
+
+func TestHandlerRedactsListItems(t *testing.T) {
+
+	t.Cleanup(Reset)
+	Set("handlerServers", []interface{}{
+		map[string]interface{}{"host": "first", "password": "hunter2"},
+		map[string]interface{}{"host": "second", "auth": map[string]interface{}{"token": "abc"}},
+	})
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+
+	var report configReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got, _ := json.Marshal(report.Config["handlerservers"])
+	want := `[{"host":"first","password":"[redacted]"},{"auth":{"token":"[redacted]"},"host":"second"}]`
+	if string(got) != want {
+		t.Errorf("\ngot:  %s\nwant: %s\n", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := DumpRedacted(&buf, DumpFlat); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if strings.Contains(buf.String(), "hunter2") || strings.Contains(buf.String(), "abc") {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestHandlerReloadMethod(t *testing.T) {
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config/reload", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("\ngot:  %v\nwant: %v\n", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
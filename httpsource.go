@@ -0,0 +1,175 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// HTTPOptions configures the connection of an HTTPSource
+type HTTPOptions struct {
+	caFile    string
+	certFile  string
+	keyFile   string
+	insecure  bool
+	proxy     string
+	username  string
+	password  string
+	token     TokenFunc
+	transport http.RoundTripper
+}
+
+// TokenFunc returns the bearer token for a request. Refresh is set when the server rejected the last token,
+// so a new token should be requested instead of returning a cached one.
+type TokenFunc func(refresh bool) (string, error)
+
+// CAFile trusts the PEM encoded certificates in the file in addition to the system roots
+func CAFile(file string) func(*HTTPOptions) {
+	return func(o *HTTPOptions) {
+		o.caFile = file
+	}
+}
+
+// ClientCert authenticates with the PEM encoded certificate and key files for mutual TLS
+func ClientCert(certFile string, keyFile string) func(*HTTPOptions) {
+	return func(o *HTTPOptions) {
+		o.certFile = certFile
+		o.keyFile = keyFile
+	}
+}
+
+// InsecureSkipVerify doesn't verify the server certificate. Only use it for development.
+func InsecureSkipVerify(o *HTTPOptions) { o.insecure = true }
+
+// Proxy connects through the proxy URL instead of the proxy from the HTTP_PROXY and HTTPS_PROXY environment
+func Proxy(proxyURL string) func(*HTTPOptions) {
+	return func(o *HTTPOptions) {
+		o.proxy = proxyURL
+	}
+}
+
+// BasicAuth authenticates with the username and password
+func BasicAuth(username string, password string) func(*HTTPOptions) {
+	return func(o *HTTPOptions) {
+		o.username = username
+		o.password = password
+	}
+}
+
+// BearerToken authenticates with a static bearer token
+func BearerToken(token string) func(*HTTPOptions) {
+	return Token(func(bool) (string, error) { return token, nil })
+}
+
+// Token authenticates with the bearer tokens returned by the function,
+// which is asked for a new token once when the server responds with 401 Unauthorized
+func Token(f TokenFunc) func(*HTTPOptions) {
+	return func(o *HTTPOptions) {
+		o.token = f
+	}
+}
+
+// RoundTripper sends the requests with a custom RoundTripper, like one that adds credentials.
+// The TLS and proxy options are not used with a custom RoundTripper.
+func RoundTripper(rt http.RoundTripper) func(*HTTPOptions) {
+	return func(o *HTTPOptions) {
+		o.transport = rt
+	}
+}
+
+// HTTPSource returns a FetchFunc that gets the config from the URL, to be used with PollSource.
+// Fails when the certificate files or the proxy URL are invalid.
+func HTTPSource(sourceURL string, options ...func(*HTTPOptions)) (FetchFunc, error) {
+	var opts HTTPOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	transport := opts.transport
+	if transport == nil {
+		t, err := opts.httpTransport()
+		if err != nil {
+			return nil, err
+		}
+		transport = t
+	}
+	client := &http.Client{Transport: transport}
+	return func() (b []byte, err error) {
+		end := startSpan("cfg.fetch.http")
+		defer func() { end(err) }()
+		resp, err := opts.get(client, sourceURL, false)
+		if err == nil && resp.StatusCode == http.StatusUnauthorized && opts.token != nil {
+			resp.Body.Close()
+			resp, err = opts.get(client, sourceURL, true)
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("fetching %s: %s", sourceURL, resp.Status)
+		}
+		return io.ReadAll(resp.Body)
+	}, nil
+}
+
+// get sends the request with the credentials
+func (o *HTTPOptions) get(client *http.Client, sourceURL string, refresh bool) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, sourceURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if o.username != "" {
+		req.SetBasicAuth(o.username, o.password)
+	}
+	if o.token != nil {
+		token, err := o.token(refresh)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return client.Do(req)
+}
+
+// httpTransport returns the HTTP transport with the TLS and proxy settings
+func (o *HTTPOptions) httpTransport() (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = &tls.Config{InsecureSkipVerify: o.insecure}
+	if o.caFile != "" {
+		pem, err := os.ReadFile(o.caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", o.caFile)
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+	if o.certFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.certFile, o.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	}
+	if o.proxy != "" {
+		u, err := url.Parse(o.proxy)
+		if err != nil {
+			return nil, err
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+	return t, nil
+}
@@ -0,0 +1,136 @@
+package cfg
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHTTPSourceTLS(t *testing.T) {
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("name: remote\n"))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := ioutil.WriteFile(caFile, ca, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		options []func(*HTTPOptions)
+		wantErr bool
+	}{
+		{"untrusted", nil, true},
+		{"ca", []func(*HTTPOptions){CAFile(caFile)}, false},
+		{"insecure", []func(*HTTPOptions){InsecureSkipVerify}, false},
+	}
+
+	for _, test := range tests {
+		fetch, err := HTTPSource(srv.URL, test.options...)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		b, err := fetch()
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if !test.wantErr && string(b) != "name: remote\n" {
+			t.Errorf("%s\ngot:  %q\nwant: %q\n", test.name, b, "name: remote\n")
+		}
+	}
+
+	if _, err := HTTPSource(srv.URL, CAFile(filepath.Join(dir, "missing.pem"))); err == nil {
+		t.Errorf("Expected an error for a missing CA file")
+	}
+}
+
+func TestHTTPSourceProxy(t *testing.T) {
+
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.Write([]byte("name: proxied\n"))
+	}))
+	defer proxy.Close()
+
+	fetch, err := HTTPSource("http://config.example/app.yaml", Proxy(proxy.URL))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := fetch(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if proxied != "http://config.example/app.yaml" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", proxied, "http://config.example/app.yaml")
+	}
+}
+
+func TestHTTPSourceAuth(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if r.Header.Get("Authorization") != "Bearer fresh" && !(ok && user == "admin" && pass == "secret") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("name: private\n"))
+	}))
+	defer srv.Close()
+
+	var refreshed bool
+	token := func(refresh bool) (string, error) {
+		if refresh {
+			refreshed = true
+			return "fresh", nil
+		}
+		return "expired", nil
+	}
+
+	tests := []struct {
+		name    string
+		options []func(*HTTPOptions)
+		wantErr bool
+	}{
+		{"anonymous", nil, true},
+		{"basic", []func(*HTTPOptions){BasicAuth("admin", "secret")}, false},
+		{"bearer", []func(*HTTPOptions){BearerToken("fresh")}, false},
+		{"refresh", []func(*HTTPOptions){Token(token)}, false},
+		{"roundtripper", []func(*HTTPOptions){RoundTripper(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			r.SetBasicAuth("admin", "secret")
+			return http.DefaultTransport.RoundTrip(r)
+		}))}, false},
+	}
+
+	for _, test := range tests {
+		fetch, err := HTTPSource(srv.URL, test.options...)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := fetch(); (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+	}
+
+	if !refreshed {
+		t.Errorf("Expected the token to be refreshed")
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
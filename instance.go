@@ -0,0 +1,235 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Config is a config file that is read separately from the global config,
+// so commands of one program can use different config files
+type Config struct {
+	mu   sync.RWMutex
+	once sync.Once
+	v    *viper.Viper
+	file string
+	// ns roots a Namespace view at a section of the base config, or the global config when base is nil.
+	// Shared views don't fall back to the root and write only their section.
+	ns     string
+	base   *Config
+	shared bool
+}
+
+// NewConfig returns a Config reading the file, which is read when first accessed.
+// A missing file is an empty config that is created on Write.
+func NewConfig(file string) *Config {
+	if expanded, err := homedir.Expand(file); err == nil {
+		file = expanded
+	}
+	v := viper.New()
+	v.SetConfigFile(file)
+	v.AutomaticEnv()
+	return &Config{v: v, file: file}
+}
+
+// UseConfig binds the Struct with the Config instead of the global config
+func UseConfig(c *Config) func(*BindOptions) {
+	return func(o *BindOptions) {
+		o.config = c
+	}
+}
+
+func (c *Config) load() {
+	c.once.Do(func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if err := c.v.ReadInConfig(); err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(WarningOutput, "Warning: %s (%s)\n", err, c.file)
+		}
+	})
+}
+
+// File returns the path of the config file
+func (c *Config) File() string {
+	if c.ns != "" {
+		return c.baseFile()
+	}
+	return c.file
+}
+
+func (c *Config) Get(key string) interface{} {
+	if c.ns != "" {
+		return c.baseGet(c.nsKey(key))
+	}
+	c.load()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.v.Get(key)
+}
+
+func (c *Config) GetString(key string) string {
+	if c.ns != "" {
+		return c.baseGetString(c.nsKey(key))
+	}
+	c.load()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.v.GetString(key)
+}
+
+func (c *Config) GetInt(key string) int {
+	if c.ns != "" {
+		return c.baseGetInt(c.nsKey(key))
+	}
+	c.load()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.v.GetInt(key)
+}
+
+func (c *Config) IsSet(key string) bool {
+	if c.ns != "" {
+		return c.baseIsSet(c.nsKey(key))
+	}
+	c.load()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.v.IsSet(key)
+}
+
+func (c *Config) Set(key string, value interface{}) {
+	if c.ns != "" {
+		c.baseSet(c.ns+"."+key, value)
+		return
+	}
+	c.load()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.v.Set(key, value)
+}
+
+// AllSettings returns the settings of the Config. The settings of a Namespace are merged into the root settings.
+func (c *Config) AllSettings() map[string]interface{} {
+	if c.ns != "" {
+		return c.nsSettings("")
+	}
+	c.load()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.v.AllSettings()
+}
+
+// Write writes the Config to its file, which is the file of the base config for a Namespace
+func (c *Config) Write() error {
+	if c.shared {
+		return c.writeSection()
+	}
+	if c.ns != "" {
+		return c.baseWrite()
+	}
+	c.load()
+	c.mu.RLock()
+	err := c.v.WriteConfig()
+	c.mu.RUnlock()
+	if err != nil {
+		return configError(err)
+	}
+	fmt.Println("Writing config:", c.file)
+	return nil
+}
+
+// Reload reads the config file again
+func (c *Config) Reload() error {
+	if c.ns != "" {
+		return c.baseReload()
+	}
+	c.load()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return configError(c.v.ReadInConfig())
+}
+
+// settings returns the settings at the key, or all settings when the key is empty
+func (c *Config) settings(key string) map[string]interface{} {
+	if c.ns != "" {
+		return c.nsSettings(key)
+	}
+	c.load()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if key == "" {
+		return c.v.AllSettings()
+	}
+	return c.v.GetStringMap(key)
+}
+
+func (c *Config) bindPFlag(key string, flag *pflag.Flag) error {
+	if c.ns != "" {
+		return c.baseBindPFlag(c.ns+"."+key, flag)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.v.BindPFlag(key, flag)
+}
+
+// settings returns the settings at the key of the global config or the Config
+func (o *BindOptions) settings() map[string]interface{} {
+	if o.config != nil {
+		return o.config.settings(o.key)
+	}
+	return cachedSettings(o.key)
+}
+
+// isSet reports whether the key is set in the global config or the Config
+func (o *BindOptions) isSet(key string) bool {
+	if o.config != nil {
+		return o.config.IsSet(key)
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	return viper.IsSet(key)
+}
+
+// set sets the value in the global config or the Config
+func (o *BindOptions) set(key string, value interface{}) {
+	if o.config != nil {
+		o.config.Set(key, value)
+		return
+	}
+	Set(key, value)
+}
+
+// write writes the global config or the Config
+func (o *BindOptions) write() error {
+	if o.config != nil {
+		return o.config.Write()
+	}
+	return Write()
+}
+
+// bindPFlag binds the flag to the key of the global config or the Config
+func (o *BindOptions) bindPFlag(key string, flag *pflag.Flag) error {
+	if o.config != nil {
+		return o.config.bindPFlag(key, flag)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return bindGlobalPFlag(key, flag)
+}
+
+// configFile returns the file of the global config or the Config
+func (o *BindOptions) configFile() string {
+	if o.config != nil {
+		return o.config.File()
+	}
+	return configFile()
+}
@@ -0,0 +1,76 @@
+package cfg
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+type instanceStruct struct {
+	Addr string
+}
+
+func TestUseConfig(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	serverFile := filepath.Join(dir, "server.yaml")
+	if err := ioutil.WriteFile(serverFile, []byte("server:\n  addr: :8080\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	clientFile := filepath.Join(dir, "client.yaml")
+	if err := ioutil.WriteFile(clientFile, []byte("client:\n  addr: example.com:8080\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var server, client instanceStruct
+	var serverConfig *Config
+
+	rootCmd := &cobra.Command{Use: "root"}
+	serverCmd := &cobra.Command{
+		Use: "server",
+		Run: func(cmd *cobra.Command, _ []string) {
+			serverConfig, _ = FromContext[Config](cmd.Context())
+		},
+	}
+	clientCmd := &cobra.Command{
+		Use: "client",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+	rootCmd.AddCommand(serverCmd, clientCmd)
+
+	serverCfg := NewConfig(serverFile)
+	BindFlags(serverCmd, &server, Key("server"), UseConfig(serverCfg), ToContext)
+	BindFlags(clientCmd, &client, Key("client"), UseConfig(NewConfig(clientFile)), SaveFlag)
+
+	rootCmd.SetArgs([]string{"server"})
+	if err := ExecuteContext(context.Background(), rootCmd); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if server.Addr != ":8080" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", server.Addr, ":8080")
+	}
+	if serverConfig != serverCfg {
+		t.Errorf("Expected the Config in the context")
+	}
+
+	if _, err := executeCommand(rootCmd, "client", "--addr", "localhost:9090", "--save"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if client.Addr != "localhost:9090" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", client.Addr, "localhost:9090")
+	}
+	if got := NewConfig(clientFile).GetString("client.addr"); got != "localhost:9090" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "localhost:9090")
+	}
+	if got := Get("client"); got != nil {
+		t.Errorf("Expected the global config to be unchanged: %v", got)
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// layers holds the config layers cfg keeps itself, because viper can't remove values from its layers.
+// Viper's config layer is rebuilt from the file settings and the fragments merged into them,
+// and viper's override layer holds the values that were Set with the credentials and the overrideStack on top.
+// Guarded by mu.
+var layers struct {
+	// file holds the settings of the config file with unresolved !exec values
+	file map[string]interface{}
+	// fragments are merged into the file settings in order
+	fragments []fragment
+	// set holds the values that were Set as nested maps with lowercase keys
+	set map[string]interface{}
+	// cleared holds the values that replace removed overrides by top-level key
+	cleared map[string]interface{}
+	// credentials holds the systemd credentials by their lowercase dot keys, which are never written
+	credentials map[string]interface{}
+}
+
+// fragment is a config document merged with MergeReader, MergeMap or PollSource
+type fragment struct {
+	// source identifies the PollSource that fetched the document, 0 for merged documents
+	source   int
+	settings map[string]interface{}
+	mode     MergeMode
+}
+
+// configSettings returns the file settings with the fragments merged into them.
+// The caller must hold the lock.
+func configSettings() map[string]interface{} {
+	return mergeFragments(deepCopy(layers.file).(map[string]interface{}))
+}
+
+// mergeFragments merges the fragments into the settings. The caller must hold the lock.
+func mergeFragments(settings map[string]interface{}) map[string]interface{} {
+	for _, f := range layers.fragments {
+		settings = f.merge(settings)
+	}
+	return settings
+}
+
+// readConfigFile reads the settings of the config file, verifying its signature when a key is set.
+// The caller must hold the write lock.
+func readConfigFile(file string) (map[string]interface{}, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, configError(err)
+	}
+	if err := verifyConfigData(file, b); err != nil {
+		return nil, err
+	}
+	if t := configType(file); t == "yaml" || t == "yml" {
+		b = quoteExecTags(b)
+	}
+	v := viper.New()
+	v.SetConfigType(configType(file))
+	if err := v.ReadConfig(bytes.NewReader(b)); err != nil {
+		return nil, err
+	}
+	return v.AllSettings(), nil
+}
+
+// configType returns the format of the config file by its extension, yaml when it has none
+func configType(file string) string {
+	if ext := filepath.Ext(file); len(ext) > 1 {
+		return ext[1:]
+	}
+	return "yaml"
+}
+
+// overlay sets the values of src in dst, merging nested maps
+func overlay(dst map[string]interface{}, src map[string]interface{}) map[string]interface{} {
+	for k, v := range src {
+		srcMap, srcOk := v.(map[string]interface{})
+		dstMap, dstOk := toStringMap(dst[k])
+		if srcOk && dstOk {
+			dst[k] = overlay(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+// merge merges the fragment into the settings with its merge mode
+func (f fragment) merge(settings map[string]interface{}) map[string]interface{} {
+	src := deepCopy(f.settings).(map[string]interface{})
+	if f.mode == HelmMerge {
+		return helmMerge(settings, src)
+	}
+	v := viper.New()
+	v.MergeConfigMap(settings)
+	v.MergeConfigMap(src)
+	return v.AllSettings()
+}
+
+// rebuildConfig replaces viper's config layer with the file settings, the merged fragments
+// and the resolved !exec values. The caller must hold the write lock.
+func rebuildConfig() error {
+	var settings interface{} = configSettings()
+	for key, r := range execRefs {
+		if value, ok := lookupKey(settings, key); !ok || value != r.ref {
+			continue
+		}
+		if steps, err := parsePath(key); err == nil {
+			settings, _ = setStep(settings, steps, r.value)
+		}
+	}
+	return replaceConfig(settings.(map[string]interface{}))
+}
+
+// viperConfig returns the settings in viper's config layer, like the config a ConfigLoader
+// read with viper.ReadConfig. The caller must hold the write lock.
+func viperConfig() map[string]interface{} {
+	tops := overriddenKeys()
+	for top := range layers.cleared {
+		tops[top] = true
+	}
+	for top := range tops {
+		viper.Set(top, nil)
+	}
+	settings := make(map[string]interface{})
+	for key := range viper.AllSettings() {
+		if viper.InConfig(key) {
+			settings[key] = lowerKeys(deepCopy(viper.Get(key)))
+		}
+	}
+	for top := range tops {
+		if value, ok := overrideValue(top); ok {
+			viper.Set(top, value)
+		} else {
+			viper.Set(top, layers.cleared[top])
+		}
+	}
+	return settings
+}
+
+// setOverride sets the value of the key in viper's override layer and records it.
+// The caller must hold the write lock.
+func setOverride(key string, value interface{}) {
+	if layers.set == nil {
+		layers.set = make(map[string]interface{})
+	}
+	path := strings.Split(strings.ToLower(key), ".")
+	setPath(layers.set, path, lowerKeys(deepCopy(value)))
+	syncOverride(path[0])
+}
+
+// lookupOverride returns the value that was Set for the key. The caller must hold the lock.
+func lookupOverride(key string) (interface{}, bool) {
+	return lookupKey(layers.set, key)
+}
+
+// removeOverride removes the value that was Set for the key and reports whether there was one.
+// The caller must hold the write lock.
+func removeOverride(key string) bool {
+	path := strings.Split(strings.ToLower(key), ".")
+	if !deletePath(layers.set, path) {
+		return false
+	}
+	syncOverride(path[0])
+	return true
+}
+
+// syncOverride makes viper's override of the top-level key match the values that were Set and pushed.
+// Viper can't delete overrides, so a removed override is replaced with a value viper looks past:
+// an empty map when other layers have keys below it, which a nil value would hide from AllKeys,
+// and nil otherwise. The caller must hold the write lock.
+func syncOverride(top string) {
+	if value, ok := overrideValue(top); ok {
+		delete(layers.cleared, top)
+		viper.Set(top, value)
+		return
+	}
+	var placeholder interface{}
+	if hasNestedKeys(top) {
+		placeholder = map[string]interface{}{}
+	}
+	if layers.cleared == nil {
+		layers.cleared = make(map[string]interface{})
+	}
+	layers.cleared[top] = placeholder
+	viper.Set(top, placeholder)
+}
+
+// hasNestedKeys reports whether the config, the defaults, the bound flags or environment variables
+// have keys below the top-level key.
+// The caller must hold the lock.
+func hasNestedKeys(top string) bool {
+	if _, ok := toStringMap(configSettings()[top]); ok {
+		return true
+	}
+	for key := range defaultValues {
+		if strings.HasPrefix(key, top+".") {
+			return true
+		}
+	}
+	for key := range boundFlags {
+		if strings.HasPrefix(key, top+".") {
+			return true
+		}
+	}
+	for key := range envSettings.bound {
+		if strings.HasPrefix(key, top+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// allKeys returns the keys of all settings like viper.AllKeys, which lists a removed override
+// instead of the keys below it. The caller must hold the lock.
+func allKeys() []string {
+	flat := make(map[string]interface{})
+	flattenSettings(viper.AllSettings(), "", flat)
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// getValue returns the value of the key like viper.Get, except that sections are merged from all layers.
+// Viper only returns the values that were Set for a section when any were. The caller must hold the lock.
+func getValue(key string) interface{} {
+	value := viper.Get(key)
+	if _, ok := toStringMap(value); ok {
+		return sectionSettings(key)
+	}
+	return value
+}
+
+// sectionSettings returns the merged settings at the key like viper.GetStringMap.
+// The caller must hold the lock.
+func sectionSettings(key string) map[string]interface{} {
+	value, _ := lookupKey(viper.AllSettings(), key)
+	if m, ok := toStringMap(value); ok {
+		return m
+	}
+	return map[string]interface{}{}
+}
+
+// lookupKey returns the value at the dot key in the nested maps, matching case-insensitively
+func lookupKey(settings interface{}, key string) (interface{}, bool) {
+	value := settings
+	for _, k := range strings.Split(key, ".") {
+		m, ok := toStringMap(value)
+		if !ok {
+			return nil, false
+		}
+		found := false
+		for name, v := range m {
+			if strings.EqualFold(name, k) {
+				value, found = v, true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// pruneEmpty removes the empty maps from the nested maps
+func pruneEmpty(m map[string]interface{}) {
+	for k, v := range m {
+		if child, ok := v.(map[string]interface{}); ok {
+			pruneEmpty(child)
+			if len(child) == 0 {
+				delete(m, k)
+			}
+		}
+	}
+}
+
+// deletePath removes the value at the path from the nested maps, and the maps that are left empty
+func deletePath(m map[string]interface{}, path []string) bool {
+	if len(path) == 1 {
+		_, ok := m[path[0]]
+		delete(m, path[0])
+		return ok
+	}
+	child, ok := m[path[0]].(map[string]interface{})
+	if !ok || !deletePath(child, path[1:]) {
+		return false
+	}
+	if len(child) == 0 {
+		delete(m, path[0])
+	}
+	return true
+}
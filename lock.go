@@ -0,0 +1,55 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// LockFiles takes an advisory lock on a .lock file next to the config file while writing it,
+// so processes writing the same config don't lose each other's edits.
+// The .lock file is left next to the config file, so all processes lock the same file.
+var LockFiles = false
+
+// configModTime is the modification time of the config file when it was last read or written. Guarded by mu.
+var configModTime time.Time
+
+// lockConfig takes the advisory lock of the config file and returns the function that releases it
+func lockConfig(file string) (func(), error) {
+	if !LockFiles || file == "" {
+		return func() {}, nil
+	}
+	f, err := os.OpenFile(file+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// stampConfig records the modification time and checksum of the config file. The caller must hold the lock.
+func stampConfig() {
+	configModTime = time.Time{}
+	if info, err := os.Stat(viper.ConfigFileUsed()); err == nil {
+		configModTime = info.ModTime()
+	}
+	configSum = fileChecksum(viper.ConfigFileUsed())
+}
+
+// configChanged reports whether another process wrote the config file since it was read.
+// The caller must hold the lock.
+func configChanged(file string) bool {
+	info, err := os.Stat(file)
+	return err == nil && (!info.ModTime().Equal(configModTime) || fileChecksum(file) != configSum)
+}
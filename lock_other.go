@@ -0,0 +1,14 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package cfg
+
+import "os"
+
+// lockFile does nothing on platforms without file locking
+func lockFile(f *os.File) error { return nil }
+
+func unlockFile(f *os.File) error { return nil }
@@ -0,0 +1,86 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestTxKeepsExternalEdits(t *testing.T) {
+
+	Reset()
+	defer Reset()
+	defer func(lock bool) { LockFiles = lock }(LockFiles)
+	LockFiles = true
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(file, []byte("lockSection:\n  name: first\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ReadInConfig()
+	viper.SetConfigFile(file)
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	// another process edits the file
+	if err := ioutil.WriteFile(file, []byte("lockSection:\n  name: first\n  external: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(file, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := Begin()
+	tx.Set("lockSection.name", "second")
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := GetString("lockSection.name"); got != "second" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "second")
+	}
+
+	if got := Get("lockSection.external"); got != true {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, true)
+	}
+
+	if _, err := os.Stat(file + ".lock"); err != nil {
+		t.Errorf("Expected a lock file: %v", err)
+	}
+}
+
+func TestWriteKeepsExternalEdits(t *testing.T) {
+
+	file := useConfigFile(t, "writeSection:\n  name: first\n")
+	SetDefault("writeSection.port", 80)
+	Set("writeSection.name", "second")
+
+	// another process edits the file
+	if err := ioutil.WriteFile(file, []byte("writeSection:\n  name: first\n  external: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Write(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "writesection:\n  external: true\n  name: second\n"; string(b) != want {
+		t.Errorf("\ngot:  %q\nwant: %q\n", b, want)
+	}
+}
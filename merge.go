@@ -0,0 +1,138 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"path"
+	"reflect"
+	"strings"
+)
+
+// SliceMerge controls how a slice passed as a flag combines with the slice from the config
+type SliceMerge int
+
+const (
+	// ReplaceSlice replaces the config values with the flag values
+	ReplaceSlice SliceMerge = iota
+	// AppendSlice appends the flag values to the config values
+	AppendSlice
+	// UniqueSlice appends the flag values to the config values, skipping values that are already present
+	UniqueSlice
+)
+
+// SliceMergeStrategy sets how slice flags combine with the config for the binding
+func SliceMergeStrategy(s SliceMerge) func(*BindOptions) {
+	return func(o *BindOptions) {
+		o.sliceMerge = s
+	}
+}
+
+// merge combines the lower and higher precedence slices
+func (s SliceMerge) merge(lower reflect.Value, higher reflect.Value) reflect.Value {
+	if s == ReplaceSlice {
+		return higher
+	}
+	merged := reflect.MakeSlice(lower.Type(), 0, lower.Len()+higher.Len())
+	merged = reflect.AppendSlice(merged, lower)
+	for i := 0; i < higher.Len(); i++ {
+		if s == UniqueSlice && containsValue(merged, higher.Index(i)) {
+			continue
+		}
+		merged = reflect.Append(merged, higher.Index(i))
+	}
+	return merged
+}
+
+// containsValue reports whether the slice contains the value
+func containsValue(slice reflect.Value, v reflect.Value) bool {
+	for i := 0; i < slice.Len(); i++ {
+		if reflect.DeepEqual(slice.Index(i).Interface(), v.Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneFields copies the slice and map fields of the Struct value.
+// Decoding writes into existing slices and maps, so they must not be shared with the flag values or defaults.
+func cloneFields(rv reflect.Value) {
+	for i := 0; i < rv.NumField(); i++ {
+		fv := rv.Field(i)
+		if !fv.CanSet() {
+			continue
+		}
+		switch fv.Kind() {
+		case reflect.Slice:
+			if fv.IsNil() {
+				continue
+			}
+			clone := reflect.MakeSlice(fv.Type(), fv.Len(), fv.Len())
+			reflect.Copy(clone, fv)
+			fv.Set(clone)
+		case reflect.Map:
+			if fv.IsNil() {
+				continue
+			}
+			clone := reflect.MakeMapWithSize(fv.Type(), fv.Len())
+			for _, k := range fv.MapKeys() {
+				clone.SetMapIndex(k, fv.MapIndex(k))
+			}
+			fv.Set(clone)
+		case reflect.Struct:
+			cloneFields(fv)
+		}
+	}
+}
+
+// ReplaceMaps replaces the values of nested maps and Structs wholesale when their key is set in the config,
+// instead of deep-merging them with the defaults. Patterns match the dotted keys relative to the binding Key,
+// like "credentials" or "servers.*", using path.Match.
+func ReplaceMaps(patterns ...string) func(*BindOptions) {
+	return func(o *BindOptions) {
+		o.replaceMaps = append(o.replaceMaps, patterns...)
+	}
+}
+
+// replacesMap reports whether the key matches one of the ReplaceMaps patterns
+func (o *BindOptions) replacesMap(key string) bool {
+	for _, pattern := range o.replaceMaps {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(key)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// clearReplaced zeroes the nested maps and Structs that are replaced by the config
+func (o *BindOptions) clearReplaced(rv reflect.Value, prefix string) {
+	if len(o.replaceMaps) == 0 {
+		return
+	}
+	rt := rv.Type()
+	for i := 0; i < rv.NumField(); i++ {
+		fv := rv.Field(i)
+		if !fv.CanSet() || (fv.Kind() != reflect.Map && fv.Kind() != reflect.Struct) {
+			continue
+		}
+		key := rt.Field(i).Name
+		if prefix == "" {
+			key = o.keyName(key)
+		} else {
+			key = prefix + "." + key
+		}
+		fullKey := key
+		if o.key != "" {
+			fullKey = o.key + "." + key
+		}
+		if !o.isSet(fullKey) {
+			continue
+		}
+		if o.replacesMap(key) {
+			fv.Set(reflect.Zero(fv.Type()))
+		} else if fv.Kind() == reflect.Struct {
+			o.clearReplaced(fv, key)
+		}
+	}
+}
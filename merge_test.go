@@ -0,0 +1,92 @@
+package cfg
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+type sliceStruct struct {
+	Tags []string
+}
+
+func TestResolveSliceMergeStrategy(t *testing.T) {
+
+	Set("sliceSection", map[string]interface{}{
+		"tags": []string{"base", "shared"},
+	})
+
+	tests := []struct {
+		strategy SliceMerge
+		want     []string
+	}{
+		{ReplaceSlice, []string{"shared", "extra"}},
+		{AppendSlice, []string{"base", "shared", "shared", "extra"}},
+		{UniqueSlice, []string{"base", "shared", "extra"}},
+	}
+
+	for _, tt := range tests {
+		var config sliceStruct
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		CreateFlags(flags, &config)
+
+		if err := flags.Parse([]string{"--tags", "shared,extra"}); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if err := Resolve(flags, &config, Key("sliceSection"), SliceMergeStrategy(tt.strategy)); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(config.Tags, tt.want) {
+			t.Errorf("\ngot:  %v\nwant: %v\n", config.Tags, tt.want)
+		}
+	}
+}
+
+type mapStruct struct {
+	Credentials map[string]string
+	Labels      map[string]string
+}
+
+func TestResolveReplaceMaps(t *testing.T) {
+
+	Set("mapSection", map[string]interface{}{
+		"credentials": map[string]interface{}{"user": "bob"},
+		"labels":      map[string]interface{}{"team": "ops"},
+	})
+
+	defaults := func() mapStruct {
+		return mapStruct{
+			Credentials: map[string]string{"user": "admin", "password": "secret"},
+			Labels:      map[string]string{"app": "cfg"},
+		}
+	}
+
+	config := defaults()
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+
+	if err := Resolve(flags, &config, Key("mapSection"), ReplaceMaps("credentials")); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	want := mapStruct{
+		Credentials: map[string]string{"user": "bob"},
+		Labels:      map[string]string{"app": "cfg", "team": "ops"},
+	}
+
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+
+	config = defaults()
+
+	if err := Resolve(flags, &config, Key("mapSection")); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if want := "secret"; config.Credentials["password"] != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config.Credentials["password"], want)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// MergeMode selects how config fragments are merged into the loaded config
+type MergeMode int
+
+const (
+	// ViperMerge deep merges maps and only replaces a value with a value of the same type
+	ViperMerge MergeMode = iota
+	// HelmMerge merges like Helm values: maps are deep merged, other values and lists are replaced
+	// and null removes the key
+	HelmMerge
+)
+
+// ConfigMergeMode is how MergeReader, MergeMap and the sources built on them merge fragments
+var ConfigMergeMode = ViperMerge
+
+// MergeReader deep merges a config fragment in the format, like "yaml" or "json", into the loaded config
+// using the ConfigMergeMode. With the default ViperMerge, a value only replaces a value of the same type.
+// Values that were Set still take precedence.
+func MergeReader(in io.Reader, format string) error {
+	settings, err := readFragment(in, format)
+	if err != nil {
+		return err
+	}
+	return MergeMap(settings)
+}
+
+// MergeMap deep merges the settings into the loaded config like MergeReader
+func MergeMap(settings map[string]interface{}) error {
+	return mergeFragment(0, settings)
+}
+
+// readFragment decodes a config fragment in the format, keeping null values for HelmMerge
+func readFragment(in io.Reader, format string) (map[string]interface{}, error) {
+	if ConfigMergeMode == HelmMerge && (format == "yaml" || format == "yml" || format == "json") {
+		return readSettings(in, format)
+	}
+	v := viper.New()
+	v.SetConfigType(format)
+	if err := v.ReadConfig(in); err != nil {
+		return nil, err
+	}
+	return v.AllSettings(), nil
+}
+
+// mergeFragment merges the settings into the loaded config. The fragment of a source other than 0
+// replaces the fragment it merged before, so keys it no longer has are removed.
+func mergeFragment(source int, settings map[string]interface{}) error {
+	loadConfig()
+	f := fragment{source: source, settings: lowerKeys(deepCopy(settings)).(map[string]interface{}), mode: ConfigMergeMode}
+	mu.Lock()
+	replaced := false
+	for i := range layers.fragments {
+		if source != 0 && layers.fragments[i].source == source {
+			layers.fragments[i] = f
+			replaced = true
+		}
+	}
+	if !replaced {
+		layers.fragments = append(layers.fragments, f)
+	}
+	err := rebuildConfig()
+	mu.Unlock()
+	resetCache()
+	return err
+}
+
+// readSettings decodes a yaml or json document keeping null values, which viper drops
+func readSettings(in io.Reader, format string) (map[string]interface{}, error) {
+	b, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if format == "json" {
+		err = json.Unmarshal(b, &v)
+	} else {
+		err = yaml.Unmarshal(b, &v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return map[string]interface{}{}, nil
+	}
+	settings, ok := lowerKeys(v).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s document is not a map", format)
+	}
+	return settings, nil
+}
+
+// lowerKeys converts the nested maps to map[string]interface{} with lowercase keys like viper's
+func lowerKeys(v interface{}) interface{} {
+	if m, ok := toStringMap(v); ok {
+		lowered := make(map[string]interface{}, len(m))
+		for k, val := range m {
+			lowered[strings.ToLower(k)] = lowerKeys(val)
+		}
+		return lowered
+	}
+	if list, ok := v.([]interface{}); ok {
+		copied := make([]interface{}, len(list))
+		for i, val := range list {
+			copied[i] = lowerKeys(val)
+		}
+		return copied
+	}
+	return v
+}
+
+// helmMerge merges src into dst like Helm merges values: maps are merged, nil and Unset remove
+// the key and other values replace the value in dst
+func helmMerge(dst map[string]interface{}, src map[string]interface{}) map[string]interface{} {
+	for k, v := range src {
+		if v == nil || v == Unset {
+			delete(dst, k)
+			continue
+		}
+		srcMap, srcOk := v.(map[string]interface{})
+		dstMap, dstOk := toStringMap(dst[k])
+		if srcOk && dstOk {
+			dst[k] = helmMerge(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+	return dst
+}
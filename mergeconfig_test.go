@@ -0,0 +1,68 @@
+package cfg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeReader(t *testing.T) {
+
+	if err := MergeMap(map[string]interface{}{
+		"mergeSection": map[string]interface{}{"name": "Map", "port": 80},
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := MergeReader(strings.NewReader("mergeSection:\n  port: 8080\n"), "yaml"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := GetString("mergeSection.name"); got != "Map" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "Map")
+	}
+
+	if got := GetInt("mergeSection.port"); got != 8080 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, 8080)
+	}
+
+	if got := GetString("firstParam"); got != "First" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "First")
+	}
+
+	if err := MergeReader(strings.NewReader("{"), "json"); err == nil {
+		t.Errorf("Expected an error for invalid input")
+	}
+}
+
+func TestMergeReaderHelm(t *testing.T) {
+
+	ConfigMergeMode = HelmMerge
+	defer func() { ConfigMergeMode = ViperMerge }()
+
+	if err := MergeMap(map[string]interface{}{
+		"helmSection": map[string]interface{}{"name": "base", "port": 80, "hosts": []interface{}{"a", "b"}, "debug": true},
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := MergeReader(strings.NewReader(`{"helmSection": {"port": "8080", "hosts": ["c"], "debug": null}}`), "json"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := GetString("helmSection.name"); got != "base" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "base")
+	}
+
+	if got := Get("helmSection.port"); got != "8080" {
+		t.Errorf("\ngot:  %#v\nwant: %#v\n", got, "8080")
+	}
+
+	if got, want := Get("helmSection.hosts"), []interface{}{"c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, want)
+	}
+
+	if Get("helmSection.debug") != nil {
+		t.Errorf("Expected null to remove the key")
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// VersionKey is the config key holding the schema version of the config
+var VersionKey = "configVersion"
+
+// WriteMigrations rewrites the config file when it was migrated at load time
+var WriteMigrations = false
+
+// MigrationFunc upgrades the settings in place. Keys are lowercase like viper's AllSettings.
+type MigrationFunc func(settings map[string]interface{}) error
+
+type migration struct {
+	from int
+	to   int
+	f    MigrationFunc
+}
+
+// migrations holds the registered migrations
+var migrations struct {
+	sync.Mutex
+	list []migration
+}
+
+// RegisterMigration registers a function that upgrades config with schema version from to version to.
+// Migrations are chained starting at the version found under VersionKey (0 when missing)
+// and run when the config is loaded. The function runs while the config is locked and must only
+// change the settings it is given.
+func RegisterMigration(from int, to int, f MigrationFunc) {
+	if to <= from {
+		panic("Migration must upgrade to a higher version")
+	}
+	migrations.Lock()
+	defer migrations.Unlock()
+	migrations.list = append(migrations.list, migration{from: from, to: to, f: f})
+}
+
+// findMigration returns the migration starting at the version
+func findMigration(version int) (migration, bool) {
+	migrations.Lock()
+	defer migrations.Unlock()
+	for _, m := range migrations.list {
+		if m.from == version {
+			return m, true
+		}
+	}
+	return migration{}, false
+}
+
+// Migrate upgrades the settings of the loaded config file to the latest version in memory.
+// Returns the versions it migrated from and to, which are equal when nothing changed.
+func Migrate() (from int, to int, err error) {
+	loadConfig()
+	return migrateConfig()
+}
+
+func migrateConfig() (int, int, error) {
+	mu.Lock()
+	defer resetCache()
+	defer mu.Unlock()
+	settings := deepCopy(layers.file).(map[string]interface{})
+	from, to, err := migrateSettings(settings)
+	if err != nil || from == to {
+		return from, to, err
+	}
+	layers.file = settings
+	return from, to, rebuildConfig()
+}
+
+// migrateSettings upgrades the settings of a config file in place, starting at the version under VersionKey
+func migrateSettings(settings map[string]interface{}) (int, int, error) {
+	var from int
+	if value, ok := lookupKey(settings, VersionKey); ok {
+		decode(value, &from)
+	}
+	m, ok := findMigration(from)
+	if !ok {
+		return from, from, nil
+	}
+	version := from
+	for ; ok; m, ok = findMigration(version) {
+		if err := m.f(settings); err != nil {
+			return from, version, fmt.Errorf("migrating config from version %d to %d: %w", m.from, m.to, err)
+		}
+		version = m.to
+	}
+	settings[strings.ToLower(VersionKey)] = version
+	return from, version, nil
+}
+
+// upgradeSettings applies the aliases and migrations to the settings read from the config file.
+// Returns the upgraded settings and whether they were migrated. When a migration fails,
+// the settings are returned without the migrations.
+func upgradeSettings(settings map[string]interface{}, file string) (map[string]interface{}, bool, error) {
+	applyAliases(settings, file)
+	migrated := deepCopy(settings).(map[string]interface{})
+	from, to, err := migrateSettings(migrated)
+	if err != nil {
+		return settings, false, fmt.Errorf("%w (%s)", err, file)
+	}
+	return migrated, from != to, nil
+}
+
+// writeMigrations writes the config file after it was migrated while loading when WriteMigrations is set
+func writeMigrations(migrated bool) {
+	if !migrated || !WriteMigrations {
+		return
+	}
+	if err := writeConfigFile(); err != nil {
+		fmt.Fprintf(WarningOutput, "Warning: %s\n", err)
+	}
+}
+
+// writeConfigFile writes the settings of the config file, without the values from other layers
+func writeConfigFile() error {
+	mu.RLock()
+	file := viper.ConfigFileUsed()
+	mu.RUnlock()
+	unlock, err := lockConfig(file)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	mu.Lock()
+	defer mu.Unlock()
+	if err := checkUnsigned(file); err != nil {
+		return err
+	}
+	if err := writeAtomic(file, layers.file); err != nil {
+		return err
+	}
+	stampConfig()
+	return nil
+}
+
+// replaceConfig replaces the config read by viper with the settings.
+// Viper can only merge config maps, so its config is cleared by reading an empty document first,
+// which fails for formats that can't be empty after clearing it.
+// The caller must hold the write lock.
+func replaceConfig(settings map[string]interface{}) error {
+	viper.ReadConfig(bytes.NewReader(nil))
+	return viper.MergeConfigMap(settings)
+}
@@ -0,0 +1,60 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestRegisterMigration(t *testing.T) {
+
+	file := useConfigFile(t, "migrateSection:\n  oldName: Migrated\n")
+	SetDefault("migrateDefault", "Default")
+	Set("migrateSet", "Set")
+	RegisterMigration(0, 1, func(settings map[string]interface{}) error {
+		section := settings["migratesection"].(map[string]interface{})
+		section["newname"] = section["oldname"]
+		return nil
+	})
+
+	output, err := executeCommand(NewConfigCommand(), "migrate")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if want := "Migrated config from version 0 to 1\n"; output != want {
+		t.Errorf("\ngot:  %q\nwant: %q\n", output, want)
+	}
+
+	if got := GetString("migrateSection.newName"); got != "Migrated" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "Migrated")
+	}
+
+	if got := viper.GetInt(VersionKey); got != 1 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, 1)
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "configversion: 1\nmigratesection:\n  newname: Migrated\n  oldname: Migrated\n"
+	if string(b) != want {
+		t.Errorf("\ngot:  %q\nwant: %q\n", b, want)
+	}
+
+	output, err = executeCommand(NewConfigCommand(), "migrate")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if want := "Config is up to date (version 1)\n"; output != want {
+		t.Errorf("\ngot:  %q\nwant: %q\n", output, want)
+	}
+
+	if got := GetString("migrateSet"); got != "Set" || strings.Contains(string(b), "migrateset") {
+		t.Errorf("Unexpected Set value %q in %q", got, b)
+	}
+}
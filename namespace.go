@@ -0,0 +1,126 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// NamespaceKey is the key holding the sections of the namespaces
+var NamespaceKey = "tenants"
+
+// Namespace returns a view of the global config rooted at the section of the name under NamespaceKey,
+// like tenants.tenantA. Keys that are not set in the section fall back to the root of the config,
+// so shared defaults are kept at the root. Bind Structs to the view with UseConfig.
+func Namespace(name string) *Config {
+	return &Config{ns: NamespaceKey + "." + name}
+}
+
+// Namespace returns a view of the Config rooted at the section of the name under NamespaceKey
+func (c *Config) Namespace(name string) *Config {
+	return &Config{base: c, ns: NamespaceKey + "." + name}
+}
+
+// nsKey returns the key in the namespace when it is set there or the view is shared, otherwise the key at the root
+func (c *Config) nsKey(key string) string {
+	if full := c.ns + "." + key; c.shared || c.baseIsSet(full) {
+		return full
+	}
+	return key
+}
+
+// nsSettings returns the settings at the key of the root with the settings of the namespace merged into them.
+// Shared views only return the settings of their section.
+func (c *Config) nsSettings(key string) map[string]interface{} {
+	if c.shared {
+		return c.baseSettings(strings.TrimSuffix(c.ns+"."+key, "."))
+	}
+	if key == "" {
+		settings := mergeMaps(c.baseSettings(""), c.baseSettings(c.ns))
+		delete(settings, strings.ToLower(strings.SplitN(c.ns, ".", 2)[0]))
+		return settings
+	}
+	return mergeMaps(c.baseSettings(key), c.baseSettings(c.ns+"."+key))
+}
+
+func (c *Config) baseGet(key string) interface{} {
+	if c.base != nil {
+		return c.base.Get(key)
+	}
+	return Get(key)
+}
+
+func (c *Config) baseGetString(key string) string {
+	if c.base != nil {
+		return c.base.GetString(key)
+	}
+	return GetString(key)
+}
+
+func (c *Config) baseGetInt(key string) int {
+	if c.base != nil {
+		return c.base.GetInt(key)
+	}
+	return GetInt(key)
+}
+
+func (c *Config) baseIsSet(key string) bool {
+	if c.base != nil {
+		return c.base.IsSet(key)
+	}
+	loadConfig()
+	mu.RLock()
+	defer mu.RUnlock()
+	return viper.IsSet(key)
+}
+
+func (c *Config) baseSet(key string, value interface{}) {
+	if c.base != nil {
+		c.base.Set(key, value)
+		return
+	}
+	Set(key, value)
+}
+
+func (c *Config) baseSettings(key string) map[string]interface{} {
+	if c.base != nil {
+		return c.base.settings(key)
+	}
+	loadConfig()
+	return cachedSettings(key)
+}
+
+func (c *Config) baseWrite() error {
+	if c.base != nil {
+		return c.base.Write()
+	}
+	return Write()
+}
+
+func (c *Config) baseReload() error {
+	if c.base != nil {
+		return c.base.Reload()
+	}
+	return Reload()
+}
+
+func (c *Config) baseFile() string {
+	if c.base != nil {
+		return c.base.File()
+	}
+	return configFile()
+}
+
+func (c *Config) baseBindPFlag(key string, flag *pflag.Flag) error {
+	if c.base != nil {
+		return c.base.bindPFlag(key, flag)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return bindGlobalPFlag(key, flag)
+}
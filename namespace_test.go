@@ -0,0 +1,62 @@
+package cfg
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type tenantStruct struct {
+	Region  string
+	Workers int
+}
+
+func TestNamespace(t *testing.T) {
+
+	viper.Set("tenantSection", map[string]interface{}{"region": "eu", "workers": 2})
+	viper.Set("tenants.tenantA.tenantSection", map[string]interface{}{"region": "us"})
+	viper.Set("tenants.tenantA.plan", "gold")
+	viper.Set("plan", "free")
+
+	tenant := Namespace("tenantA")
+	other := Namespace("tenantB")
+
+	if got := tenant.GetString("plan"); got != "gold" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "gold")
+	}
+
+	if got := other.GetString("plan"); got != "free" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "free")
+	}
+
+	if got := tenant.GetInt("tenantSection.workers"); got != 2 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, 2)
+	}
+
+	if _, ok := tenant.AllSettings()["tenants"]; ok {
+		t.Errorf("Expected no namespaces in the settings of a namespace")
+	}
+
+	var config tenantStruct
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, Key("tenantSection"), UseConfig(tenant))
+
+	if _, err := executeCommand(rootCmd); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if want := (tenantStruct{Region: "us", Workers: 2}); config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+
+	tenant.Set("region", "ap")
+	if got := viper.GetString("tenants.tenantA.region"); got != "ap" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "ap")
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"strings"
+)
+
+// Overrides is a layer of values pushed with PushOverrides
+type Overrides struct {
+	values map[string]interface{}
+}
+
+// overrideStack holds the layers that weren't popped, oldest first. They are kept apart from the values
+// that were Set, so Write doesn't save them. Guarded by mu.
+var overrideStack []*Overrides
+
+// PushOverrides sets the values, like for a job or a request, until Pop is called.
+// Nested maps set the nested keys, so other keys in the same section are kept.
+// The overrides win over the values that were Set and are not written to the config file.
+func PushOverrides(values map[string]interface{}) *Overrides {
+	loadConfig()
+	o := &Overrides{values: make(map[string]interface{})}
+	flattenSettings(values, "", o.values)
+	mu.Lock()
+	overrideStack = append(overrideStack, o)
+	for top := range o.tops() {
+		syncOverride(top)
+	}
+	mu.Unlock()
+	resetCache()
+	return o
+}
+
+// Pop removes the overrides, so the values that were Set or pushed by the other overrides apply again.
+// Popping more than once has no effect.
+func (o *Overrides) Pop() error {
+	mu.Lock()
+	defer resetCache()
+	defer mu.Unlock()
+	for i, l := range overrideStack {
+		if l == o {
+			overrideStack = append(overrideStack[:i:i], overrideStack[i+1:]...)
+			for top := range o.tops() {
+				syncOverride(top)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// tops returns the top-level keys the overrides set
+func (o *Overrides) tops() map[string]bool {
+	tops := make(map[string]bool)
+	for key := range o.values {
+		tops[strings.SplitN(key, ".", 2)[0]] = true
+	}
+	return tops
+}
+
+// overrideValue returns the value of viper's override for the top-level key: the value that was Set
+// with the credentials and the pushed overrides set on top of it. The caller must hold the lock.
+func overrideValue(top string) (interface{}, bool) {
+	value, ok := layers.set[top]
+	value = deepCopy(value)
+	value, ok = setFlat(value, ok, top, layers.credentials)
+	for _, o := range overrideStack {
+		value, ok = setFlat(value, ok, top, o.values)
+	}
+	return value, ok
+}
+
+// setFlat sets the values of the dot keys at or below the top-level key in the value
+func setFlat(value interface{}, ok bool, top string, flat map[string]interface{}) (interface{}, bool) {
+	for key, v := range flat {
+		if key == top {
+			value, ok = lowerKeys(deepCopy(v)), true
+			continue
+		}
+		if !strings.HasPrefix(key, top+".") {
+			continue
+		}
+		m, isMap := value.(map[string]interface{})
+		if !isMap {
+			m = make(map[string]interface{})
+		}
+		setPath(m, strings.Split(key[len(top)+1:], "."), lowerKeys(deepCopy(v)))
+		value, ok = m, true
+	}
+	return value, ok
+}
+
+// overriddenKeys returns the top-level keys of the values that were Set, merged from credentials or pushed.
+// The caller must hold the lock.
+func overriddenKeys() map[string]bool {
+	tops := make(map[string]bool, len(layers.set))
+	for top := range layers.set {
+		tops[top] = true
+	}
+	for key := range layers.credentials {
+		tops[strings.SplitN(key, ".", 2)[0]] = true
+	}
+	for _, o := range overrideStack {
+		for top := range o.tops() {
+			tops[top] = true
+		}
+	}
+	return tops
+}
+
+// flattenSettings adds the leaf values of the nested settings by their lowercase dot keys
+func flattenSettings(settings map[string]interface{}, prefix string, flat map[string]interface{}) {
+	for k, v := range settings {
+		key := strings.ToLower(k)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if m, ok := toStringMap(v); ok && len(m) > 0 {
+			flattenSettings(m, key, flat)
+			continue
+		}
+		flat[key] = v
+	}
+}
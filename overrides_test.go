@@ -0,0 +1,115 @@
+package cfg
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestPushOverrides(t *testing.T) {
+
+	Set("overrideSection", map[string]interface{}{"name": "Base", "port": 80})
+
+	job := PushOverrides(map[string]interface{}{
+		"overrideSection": map[string]interface{}{"port": 8080, "debug": true},
+	})
+	request := PushOverrides(map[string]interface{}{"overrideSection.port": 9090})
+
+	if got := GetInt("overrideSection.port"); got != 9090 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, 9090)
+	}
+
+	if err := job.Pop(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := GetInt("overrideSection.port"); got != 9090 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, 9090)
+	}
+
+	if got := Get("overrideSection.debug"); got != nil {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, nil)
+	}
+
+	if err := request.Pop(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := GetInt("overrideSection.port"); got != 80 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, 80)
+	}
+
+	if got := GetString("overrideSection.name"); got != "Base" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "Base")
+	}
+
+	if err := request.Pop(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestPopKeepsLayers(t *testing.T) {
+
+	useConfigFile(t, "popSection:\n  name: File\n")
+	t.Setenv("POPHOST", "Env")
+	viper.AutomaticEnv()
+	SetDefault("popPort", 80)
+
+	o := PushOverrides(map[string]interface{}{"popPort": 8080, "popHost": "Job", "popSection": map[string]interface{}{"name": "Job"}})
+	if got := GetString("popHost"); got != "Job" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "Job")
+	}
+
+	if err := o.Pop(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for key, want := range map[string]string{"popHost": "Env", "popPort": "80", "popSection.name": "File"} {
+		if got := GetString(key); got != want {
+			t.Errorf("\ngot:  %v\nwant: %v\n", got, want)
+		}
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	if viper.InConfig("popPort") || viper.InConfig("popHost") {
+		t.Errorf("Unexpected config values: %v", layers.file)
+	}
+}
+
+func TestWriteSkipsOverrides(t *testing.T) {
+
+	file := useConfigFile(t, "writeSection:\n  name: File\n")
+
+	o := PushOverrides(map[string]interface{}{"writeSection": map[string]interface{}{"name": "Job"}, "writeJob": "Job"})
+	Set("writeSection.name", "Set")
+
+	if got := GetString("writeSection.name"); got != "Job" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "Job")
+	}
+
+	if err := Write(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), "writesection:\n  name: Set\n"; got != want {
+		t.Errorf("\ngot:  %q\nwant: %q\n", got, want)
+	}
+
+	if got := GetString("writeJob"); got != "Job" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "Job")
+	}
+
+	if err := o.Pop(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := GetString("writeSection.name"); got != "Set" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "Set")
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/pflag"
+)
+
+// DirMode is the permission of the directories created for fields with a `path:"dir,create"` tag
+var DirMode os.FileMode = 0755
+
+// resolvePaths expands and checks the string fields with a `path:"file"` or `path:"dir"` tag.
+// Environment variables and ~ are expanded, and relative paths are resolved against the directory
+// of the config file, except when they were given as flag or argument.
+// With `path:"file,exists"` or `path:"dir,exists"` the path must be a readable file or a directory.
+// With `path:"dir,create"` the directory is created, and with `path:"file,create"` the directory of the file.
+func resolvePaths(flags *pflag.FlagSet, rawVal interface{}, opts *BindOptions) error {
+	rv := structValue(rawVal)
+	var dir string
+	for _, f := range structFields(rv.Type()) {
+		fv := rv.Field(f.index)
+		if f.path == "" || fv.String() == "" {
+			continue
+		}
+		path, err := homedir.Expand(os.ExpandEnv(fv.String()))
+		if err != nil {
+			return fieldError(f, opts, err.Error())
+		}
+		if !filepath.IsAbs(path) && !fromCommandLine(flags, f, opts) {
+			if dir == "" {
+				dir = filepath.Dir(opts.configFile())
+			}
+			path = filepath.Join(dir, path)
+		}
+		fv.SetString(path)
+		if f.pathCreate {
+			if err := createDir(path, f.path); err != nil {
+				return fieldError(f, opts, err.Error())
+			}
+		}
+		if f.pathExists {
+			if err := checkPath(path, f.path); err != nil {
+				return fieldError(f, opts, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// fromCommandLine reports whether the field value was given as flag or argument
+func fromCommandLine(flags *pflag.FlagSet, f fieldInfo, opts *BindOptions) bool {
+	if f.argIndex >= 0 {
+		return f.argIndex < len(opts.args)
+	}
+	flag := flags.Lookup(opts.flagName(f.name))
+	return flag != nil && flag.Changed
+}
+
+// createDir creates the directory, or the directory of the file, with DirMode
+func createDir(path string, kind string) error {
+	if kind != "dir" {
+		path = filepath.Dir(path)
+	}
+	return os.MkdirAll(path, DirMode)
+}
+
+// checkPath checks that the path is a readable file or a directory
+func checkPath(path string, kind string) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%s %s does not exist", kind, path)
+	}
+	if err != nil {
+		return err
+	}
+	switch {
+	case kind == "dir" && !info.IsDir():
+		return fmt.Errorf("%s is not a directory", path)
+	case kind != "dir" && info.IsDir():
+		return fmt.Errorf("%s is a directory", path)
+	case kind != "dir":
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		file.Close()
+	}
+	return nil
+}
+
+// fieldError returns the validation error of the field with the rule it broke
+func fieldError(f fieldInfo, opts *BindOptions, rule string) error {
+	if f.argIndex >= 0 {
+		return &ErrValidation{Field: f.name, Arg: opts.argName(f), Rule: rule}
+	}
+	return &ErrValidation{Field: f.name, Flag: opts.flagName(f.name), Rule: rule}
+}
@@ -0,0 +1,136 @@
+package cfg
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type pathStruct struct {
+	Data    string `path:"file,exists"`
+	Home    string `path:"dir"`
+	Logs    string `path:"dir"`
+	Missing string `path:"file,exists"`
+}
+
+func TestResolvePaths(t *testing.T) {
+
+	Reset()
+	defer Reset()
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	content := "pathSection:\n  data: data.txt\n  home: ~/app\n  logs: ${PATH_TEST_LOGS}/app\n"
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "data.txt"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("PATH_TEST_LOGS", "/var/log")
+	defer os.Unsetenv("PATH_TEST_LOGS")
+	ReadInConfig()
+	viper.SetConfigFile(file)
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	var config pathStruct
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, Key("pathSection"))
+
+	if _, err := executeCommand(rootCmd); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	home, _ := os.UserHomeDir()
+	want := pathStruct{
+		Data: filepath.Join(dir, "data.txt"),
+		Home: filepath.Join(home, "app"),
+		Logs: filepath.Join("/var/log", "app"),
+	}
+	if config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+
+	if _, err := executeCommand(rootCmd, "--data", "data.txt"); err == nil {
+		t.Errorf("Expected an error for a flag relative to the working directory")
+	}
+
+	rootCmd = &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, Key("pathSection"))
+
+	_, err = executeCommand(rootCmd, "--missing", filepath.Join(dir, "missing.txt"))
+	var validationErr *ErrValidation
+	if !errors.As(err, &validationErr) || validationErr.Flag != "missing" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+type outputStruct struct {
+	Output string `path:"dir,create"`
+	Report string `path:"file,create"`
+}
+
+func TestCreatePaths(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	DirMode = 0700
+	defer func() { DirMode = 0755 }()
+
+	config := outputStruct{
+		Output: filepath.Join(dir, "out", "data"),
+		Report: filepath.Join(dir, "reports", "report.txt"),
+	}
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, NoViper)
+
+	if _, err := executeCommand(rootCmd); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	info, err := os.Stat(config.Output)
+	if err != nil || !info.IsDir() {
+		t.Fatalf("Expected directory %s: %v", config.Output, err)
+	}
+
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", info.Mode().Perm(), os.FileMode(0700))
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "reports")); err != nil {
+		t.Errorf("Expected the directory of the file: %v", err)
+	}
+
+	if _, err := os.Stat(config.Report); !os.IsNotExist(err) {
+		t.Errorf("Expected no file: %v", err)
+	}
+}
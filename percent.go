@@ -0,0 +1,75 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Percent is a ratio between 0 and 1 that can be configured as "75%", 0.75 or 75.
+// Numbers above 1 are taken as percentages, so 0.5 and 50 are both 50%, but 1 is 100%.
+type Percent float64
+
+var percentType = reflect.TypeOf(Percent(0))
+
+// ParsePercent parses a percentage like "75%", "0.75" or "75" into a ratio between 0 and 1
+func ParsePercent(s string) (Percent, error) {
+	trimmed := strings.TrimSpace(s)
+	isPercent := strings.HasSuffix(trimmed, "%")
+	f, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(trimmed, "%")), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid percentage %q", s)
+	}
+	if isPercent {
+		f /= 100
+	}
+	return newPercent(f, isPercent)
+}
+
+// newPercent validates the ratio, converting numbers above 1 from percentages when not already converted
+func newPercent(f float64, converted bool) (Percent, error) {
+	if !converted && f > 1 {
+		f /= 100
+	}
+	if f < 0 || f > 1 {
+		return 0, fmt.Errorf("percentage %s out of range 0%%-100%%", strconv.FormatFloat(f*100, 'g', -1, 64)+"%")
+	}
+	return Percent(f), nil
+}
+
+func (p Percent) String() string { return strconv.FormatFloat(float64(p)*100, 'g', -1, 64) + "%" }
+
+// Set parses the percentage, so *Percent can be used as a flag value
+func (p *Percent) Set(s string) error {
+	v, err := ParsePercent(s)
+	if err != nil {
+		return err
+	}
+	*p = v
+	return nil
+}
+
+func (p *Percent) Type() string { return "percent" }
+
+// percentHook decodes strings and numbers into a Percent
+func percentHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if to != percentType {
+		return data, nil
+	}
+	switch v := reflect.ValueOf(data); v.Kind() {
+	case reflect.String:
+		return ParsePercent(v.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return newPercent(float64(v.Int()), false)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return newPercent(float64(v.Uint()), false)
+	case reflect.Float32, reflect.Float64:
+		return newPercent(v.Float(), false)
+	}
+	return data, nil
+}
@@ -0,0 +1,79 @@
+package cfg
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func TestParsePercent(t *testing.T) {
+
+	tests := []struct {
+		in   string
+		want Percent
+	}{
+		{"75%", 0.75},
+		{"0.75", 0.75},
+		{"75", 0.75},
+		{"1", 1},
+		{"100%", 1},
+		{"0.5%", 0.005},
+	}
+
+	for _, test := range tests {
+		got, err := ParsePercent(test.in)
+		if err != nil || got != test.want {
+			t.Errorf("ParsePercent(%q)\ngot:  %v, %v\nwant: %v\n", test.in, got, err, test.want)
+		}
+	}
+
+	for _, in := range []string{"", "%", "abc", "150%", "-5%", "101"} {
+		if _, err := ParsePercent(in); err == nil {
+			t.Errorf("ParsePercent(%q): expected an error", in)
+		}
+	}
+}
+
+type percentStruct struct {
+	SampleRate Percent
+	Threshold  Percent
+	Ratio      Percent
+}
+
+func TestBindPercent(t *testing.T) {
+
+	viper.Set("percentSection.sampleRate", "25%")
+	viper.Set("percentSection.threshold", 80)
+
+	var config percentStruct
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, Key("percentSection"))
+
+	if _, err := executeCommand(rootCmd, "--ratio", "0.5"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if want := (percentStruct{SampleRate: 0.25, Threshold: 0.8, Ratio: 0.5}); config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+
+	if _, err := executeCommand(rootCmd, "--ratio", "120%"); err == nil {
+		t.Errorf("Expected an error")
+	}
+
+	viper.Set("percentSection.threshold", 0.9)
+	var unmarshalled percentStruct
+	if err := UnmarshalKey("percentSection", &unmarshalled); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if unmarshalled.Threshold != 0.9 || unmarshalled.SampleRate != 0.25 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", unmarshalled, percentStruct{SampleRate: 0.25, Threshold: 0.9})
+	}
+}
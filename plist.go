@@ -0,0 +1,122 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// MergePlist merges a property list file, like a macOS preferences file, into the loaded config like MergeMap.
+// Binary property lists are converted with plutil, which is only available on macOS.
+func MergePlist(file string) error {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if bytes.HasPrefix(b, []byte("bplist")) {
+		if b, err = exec.Command("plutil", "-convert", "xml1", "-o", "-", file).Output(); err != nil {
+			return fmt.Errorf("converting binary plist %s: %w", file, err)
+		}
+	}
+	v, err := parsePlist(bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	settings, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s: plist root is not a dict", file)
+	}
+	return MergeMap(settings)
+}
+
+// parsePlist decodes an XML property list into maps, slices and values
+func parsePlist(r io.Reader) (interface{}, error) {
+	d := xml.NewDecoder(r)
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := t.(xml.StartElement); ok && start.Name.Local != "plist" {
+			return plistValue(d, start)
+		}
+	}
+}
+
+// plistValue decodes the element that started with start
+func plistValue(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		m := make(map[string]interface{})
+		key := ""
+		for {
+			t, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := t.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					if err := d.DecodeElement(&key, &t); err != nil {
+						return nil, err
+					}
+					key = strings.ToLower(key)
+					continue
+				}
+				v, err := plistValue(d, t)
+				if err != nil {
+					return nil, err
+				}
+				m[key] = v
+			case xml.EndElement:
+				return m, nil
+			}
+		}
+	case "array":
+		var list []interface{}
+		for {
+			t, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := t.(type) {
+			case xml.StartElement:
+				v, err := plistValue(d, t)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			case xml.EndElement:
+				return list, nil
+			}
+		}
+	case "true", "false":
+		return start.Name.Local == "true", d.Skip()
+	}
+	var s string
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return nil, err
+	}
+	switch start.Name.Local {
+	case "string", "date":
+		return s, nil
+	case "integer":
+		return strconv.Atoi(strings.TrimSpace(s))
+	case "real":
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+	case "data":
+		return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+	}
+	return nil, errors.New("unknown plist element " + start.Name.Local)
+}
@@ -0,0 +1,38 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darwin
+
+package cfg
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+)
+
+// MergePreferences merges the macOS preferences of the domain, like com.example.tool, into the loaded config
+// like MergePlist: the preferences in /Library/Preferences, then the user's ~/Library/Preferences,
+// then the managed preferences installed by MDM profiles, so managed settings take precedence.
+// Missing files are skipped. On other platforms MergePreferences does nothing.
+func MergePreferences(domain string) error {
+	name := domain + ".plist"
+	files := []string{filepath.Join("/Library/Preferences", name)}
+	if home, err := os.UserHomeDir(); err == nil {
+		files = append(files, filepath.Join(home, "Library/Preferences", name))
+	}
+	files = append(files, filepath.Join("/Library/Managed Preferences", name))
+	if u, err := user.Current(); err == nil {
+		files = append(files, filepath.Join("/Library/Managed Preferences", u.Username, name))
+	}
+	for _, file := range files {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			continue
+		}
+		if err := MergePlist(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
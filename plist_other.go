@@ -0,0 +1,11 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !darwin
+
+package cfg
+
+// MergePreferences merges the macOS preferences of the domain into the loaded config.
+// It does nothing on platforms other than macOS.
+func MergePreferences(domain string) error { return nil }
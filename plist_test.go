@@ -0,0 +1,65 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMergePlist(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "com.example.tool.plist")
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>plistSection</key>
+	<dict>
+		<key>Server</key>
+		<string>https://managed.example.com</string>
+		<key>Retries</key>
+		<integer>3</integer>
+		<key>Ratio</key>
+		<real>0.5</real>
+		<key>Enabled</key>
+		<true/>
+		<key>Hosts</key>
+		<array>
+			<string>a</string>
+			<string>b</string>
+		</array>
+	</dict>
+</dict>
+</plist>
+`
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MergePlist(file); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := GetString("plistSection.server"); got != "https://managed.example.com" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "https://managed.example.com")
+	}
+
+	if got := GetInt("plistSection.retries"); got != 3 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, 3)
+	}
+
+	if got := Get("plistSection.enabled"); got != true {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, true)
+	}
+
+	if got, want := Get("plistSection.hosts"), []interface{}{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, want)
+	}
+}
@@ -0,0 +1,326 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// FetchFunc returns the content of a config source, like a file on an HTTP server or in object storage
+type FetchFunc func() ([]byte, error)
+
+// Backoff configures retrying failed fetches with exponentially growing delays.
+// Zero fields are taken from DefaultBackoff, except Jitter.
+type Backoff struct {
+	// Initial is the delay before the first retry
+	Initial time.Duration
+	// Max is the longest delay between retries
+	Max time.Duration
+	// MaxElapsed stops retrying when this much time has passed since the first attempt.
+	// A negative MaxElapsed retries until polling stops.
+	MaxElapsed time.Duration
+	// Multiplier grows the delay after each retry, multipliers below 1 are replaced
+	Multiplier float64
+	// Jitter randomizes the delays by up to this fraction, so clients don't retry in lockstep
+	Jitter float64
+}
+
+// DefaultBackoff is used by Retry when no Backoff is given
+var DefaultBackoff = Backoff{
+	Initial:    100 * time.Millisecond,
+	Max:        10 * time.Second,
+	MaxElapsed: time.Minute,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// SourceOptions configures how PollSource fetches a source
+type SourceOptions struct {
+	backoff   *Backoff
+	cacheFile string
+	required  bool
+	signature FetchFunc
+	maxAge    time.Duration
+	stale     bool
+}
+
+// ErrStaleConfig is returned when the cached config is older than the MaxAge of its source
+var ErrStaleConfig = errors.New("stale config")
+
+// Retry retries failed fetches with the backoff, or DefaultBackoff when none is given
+func Retry(b ...Backoff) func(*SourceOptions) {
+	return func(o *SourceOptions) {
+		backoff := DefaultBackoff
+		if len(b) > 0 {
+			backoff = b[0].withDefaults()
+		}
+		o.backoff = &backoff
+	}
+}
+
+// withDefaults returns the backoff with the zero fields taken from DefaultBackoff,
+// so retries never run without a delay
+func (b Backoff) withDefaults() Backoff {
+	if b.Initial <= 0 {
+		b.Initial = DefaultBackoff.Initial
+	}
+	if b.Max <= 0 {
+		b.Max = DefaultBackoff.Max
+	}
+	if b.MaxElapsed == 0 {
+		b.MaxElapsed = DefaultBackoff.MaxElapsed
+	}
+	if b.Multiplier < 1 {
+		b.Multiplier = DefaultBackoff.Multiplier
+	}
+	return b
+}
+
+// CacheFile stores the last fetched content in the file, which is used when fetching fails at startup
+func CacheFile(file string) func(*SourceOptions) {
+	return func(o *SourceOptions) {
+		o.cacheFile = file
+	}
+}
+
+// Signature verifies the fetched content with the detached signature returned by the function,
+// like VerifySignature, before applying it
+func Signature(fetch FetchFunc) func(*SourceOptions) {
+	return func(o *SourceOptions) {
+		o.signature = fetch
+	}
+}
+
+// MaxAge warns when the config of the source is older than the duration: when the cache file used
+// at startup was written longer ago, or when fetching kept failing for longer while polling
+func MaxAge(d time.Duration) func(*SourceOptions) {
+	return func(o *SourceOptions) {
+		o.maxAge = d
+	}
+}
+
+// RefuseStale makes PollSource fail with ErrStaleConfig instead of warning when the cache file
+// used at startup is older than the MaxAge
+func RefuseStale(o *SourceOptions) { o.stale = true }
+
+// RequireSource makes PollSource fail when the source can't be fetched at startup and there is no cache.
+// Otherwise the failure is a warning and polling continues.
+func RequireSource(o *SourceOptions) { o.required = true }
+
+// Poll reads the config file every interval and reloads it when its content changed,
+// notifying the reload listeners. Call the returned function to stop polling.
+func Poll(interval time.Duration) (stop func()) {
+	fetch := func() ([]byte, error) {
+		mu.RLock()
+		file := viper.ConfigFileUsed()
+		mu.RUnlock()
+		return os.ReadFile(file)
+	}
+	loadConfig()
+	p := &poller{fetch: fetch, apply: func([]byte) error { return Reload() }, done: make(chan struct{})}
+	if b, err := fetch(); err == nil {
+		p.last = sha256.Sum256(b)
+	}
+	return p.start(interval)
+}
+
+// sources numbers the sources of PollSource
+var sources int32
+
+// PollSource fetches a config fragment in the format, like "yaml" or "json", every interval
+// and merges it like MergeReader when its content changed, notifying the reload listeners.
+// A new fragment replaces the fragment fetched before, so keys removed from the source are removed from the config.
+// The fragment is fetched and merged right away. When fetching fails later, the last fetched config is kept.
+// After Reload, the fragment is fetched and merged again even when its content didn't change.
+// Call the returned function to stop polling.
+func PollSource(fetch FetchFunc, format string, interval time.Duration, options ...func(*SourceOptions)) (stop func(), err error) {
+	var opts SourceOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	fetch = instrumentFetch(fetch)
+	if opts.signature != nil {
+		opts.signature = instrumentFetch(opts.signature)
+	}
+	source := int(atomic.AddInt32(&sources, 1))
+	merge := func(b []byte) error {
+		settings, err := readFragment(bytes.NewReader(b), format)
+		if err != nil {
+			return err
+		}
+		return mergeFragment(source, settings)
+	}
+	apply := func(b []byte) error {
+		if opts.signature != nil {
+			sig, err := opts.signature()
+			if err != nil {
+				return err
+			}
+			if err := VerifySignature(b, sig); err != nil {
+				return err
+			}
+		}
+		if err := merge(b); err != nil {
+			return err
+		}
+		reloaded()
+		if opts.cacheFile != "" {
+			return os.WriteFile(opts.cacheFile, b, 0600)
+		}
+		return nil
+	}
+	p := &poller{fetch: fetch, apply: apply, source: true, backoff: opts.backoff, maxAge: opts.maxAge, done: make(chan struct{})}
+	if err := p.check(); err != nil {
+		cached, modTime, cacheErr := readCache(opts.cacheFile)
+		switch {
+		case cacheErr == nil:
+			fmt.Fprintf(WarningOutput, "Warning: polling config: %s, using %s\n", err, opts.cacheFile)
+			if age := time.Since(modTime); opts.maxAge > 0 && age > opts.maxAge {
+				if opts.stale {
+					return nil, fmt.Errorf("%w: %s is %s old", ErrStaleConfig, opts.cacheFile, age.Round(time.Second))
+				}
+				fmt.Fprintf(WarningOutput, "Warning: %s is %s old\n", opts.cacheFile, age.Round(time.Second))
+			}
+			if err := merge(cached); err != nil {
+				return nil, err
+			}
+			p.fetched = modTime
+		case opts.required:
+			return nil, err
+		default:
+			fmt.Fprintf(WarningOutput, "Warning: polling config: %s\n", err)
+		}
+	}
+	return p.start(interval), nil
+}
+
+// instrumentFetch wraps the FetchFunc in a cfg.fetch span and counts its fetches in Stats
+func instrumentFetch(fetch FetchFunc) FetchFunc {
+	return func() ([]byte, error) {
+		end := startSpan("cfg.fetch")
+		b, err := fetch()
+		end(err)
+		recordFetch(err)
+		return b, err
+	}
+}
+
+// readCache reads the cache file and returns when it was written
+func readCache(file string) ([]byte, time.Time, error) {
+	if file == "" {
+		return nil, time.Time{}, os.ErrNotExist
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	b, err := os.ReadFile(file)
+	return b, info.ModTime(), err
+}
+
+// poller applies the fetched content whenever its checksum changed
+type poller struct {
+	fetch FetchFunc
+	apply func([]byte) error
+	// source pollers apply the content again after a Reload
+	source  bool
+	reloads uint64
+	backoff *Backoff
+	last    [sha256.Size]byte
+	fetched time.Time
+	maxAge  time.Duration
+	done    chan struct{}
+}
+
+// check fetches the content and applies it when it changed
+func (p *poller) check() error {
+	b, err := p.retry()
+	if err != nil {
+		return err
+	}
+	p.fetched = time.Now()
+	if n := atomic.LoadUint64(&reloads); p.source && n != p.reloads {
+		p.reloads = n
+		p.last = [sha256.Size]byte{}
+	}
+	sum := sha256.Sum256(b)
+	if sum == p.last {
+		return nil
+	}
+	if err := p.apply(b); err != nil {
+		return err
+	}
+	p.last = sum
+	return nil
+}
+
+// retry fetches the content, retrying with the backoff until it succeeds, the time is up or polling stops
+func (p *poller) retry() ([]byte, error) {
+	b, err := p.fetch()
+	if err == nil || p.backoff == nil {
+		return b, err
+	}
+	start := time.Now()
+	delay := p.backoff.Initial
+	for {
+		wait := jitter(delay, p.backoff.Jitter)
+		if p.backoff.MaxElapsed > 0 && time.Since(start)+wait > p.backoff.MaxElapsed {
+			return nil, err
+		}
+		select {
+		case <-time.After(wait):
+		case <-p.done:
+			return nil, err
+		}
+		if b, err = p.fetch(); err == nil {
+			return b, nil
+		}
+		delay = time.Duration(float64(delay) * p.backoff.Multiplier)
+		if p.backoff.Max > 0 && delay > p.backoff.Max {
+			delay = p.backoff.Max
+		}
+	}
+}
+
+// jitter randomizes the delay by up to the fraction
+func jitter(delay time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return delay
+	}
+	return delay + time.Duration((rand.Float64()*2-1)*fraction*float64(delay))
+}
+
+// start polls every interval until the returned function is called
+func (p *poller) start(interval time.Duration) func() {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := p.check(); err != nil {
+					fmt.Fprintf(WarningOutput, "Warning: polling config: %s\n", err)
+					if age := time.Since(p.fetched); p.maxAge > 0 && !p.fetched.IsZero() && age > p.maxAge {
+						fmt.Fprintf(WarningOutput, "Warning: config was fetched %s ago\n", age.Round(time.Second))
+					}
+				}
+			case <-p.done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(p.done) }) }
+}
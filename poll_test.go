@@ -0,0 +1,204 @@
+package cfg
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPollSource(t *testing.T) {
+
+	var mu sync.Mutex
+	content := "pollSection:\n  value: one\n"
+	fetch := func() ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return []byte(content), nil
+	}
+
+	stop, err := PollSource(fetch, "yaml", 5*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer stop()
+
+	if got := GetString("pollSection.value"); got != "one" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "one")
+	}
+
+	mu.Lock()
+	content = "pollSection:\n  value: two\n"
+	mu.Unlock()
+
+	deadline := time.Now().Add(time.Second)
+	for GetString("pollSection.value") != "two" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if got := GetString("pollSection.value"); got != "two" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "two")
+	}
+
+	stop()
+	stop()
+}
+
+func TestPollSourceRemovesKeys(t *testing.T) {
+
+	useConfigFile(t, "pollFile: kept\n")
+	var mu sync.Mutex
+	content := "pollRemoved:\n  a: one\n  b: two\n"
+	fetch := func() ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return []byte(content), nil
+	}
+
+	stop, err := PollSource(fetch, "yaml", 5*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer stop()
+
+	mu.Lock()
+	content = "pollRemoved:\n  a: one\n"
+	mu.Unlock()
+
+	deadline := time.Now().Add(time.Second)
+	for Get("pollRemoved.b") != nil && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if Get("pollRemoved.b") != nil {
+		t.Errorf("\ngot:  %v\nwant: %v\n", GetString("pollRemoved.b"), "")
+	}
+
+	if err := Reload(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for key, want := range map[string]string{"pollRemoved.a": "one", "pollFile": "kept"} {
+		if got := GetString(key); got != want {
+			t.Errorf("%s\ngot:  %v\nwant: %v\n", key, got, want)
+		}
+	}
+}
+
+func TestPollSourceRetry(t *testing.T) {
+
+	attempts := 0
+	fetch := func() ([]byte, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("unavailable")
+		}
+		return []byte("retrySection:\n  value: fetched\n"), nil
+	}
+
+	stop, err := PollSource(fetch, "yaml", time.Hour, Retry(Backoff{Initial: time.Millisecond, Multiplier: 2, MaxElapsed: time.Second}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer stop()
+
+	if got := GetString("retrySection.value"); got != "fetched" || attempts != 3 {
+		t.Errorf("\ngot:  %v after %d attempts\nwant: %v after 3 attempts\n", got, attempts, "fetched")
+	}
+}
+
+func TestRetryPartialBackoff(t *testing.T) {
+
+	var opts SourceOptions
+	Retry(Backoff{Initial: time.Millisecond, MaxElapsed: 50 * time.Millisecond})(&opts)
+
+	want := Backoff{Initial: time.Millisecond, Max: DefaultBackoff.Max, MaxElapsed: 50 * time.Millisecond, Multiplier: DefaultBackoff.Multiplier}
+	if got := *opts.backoff; got != want {
+		t.Errorf("\ngot:  %+v\nwant: %+v\n", got, want)
+	}
+
+	attempts := 0
+	p := &poller{fetch: func() ([]byte, error) {
+		attempts++
+		return nil, errors.New("unavailable")
+	}, backoff: opts.backoff, done: make(chan struct{})}
+
+	if _, err := p.retry(); err == nil {
+		t.Errorf("Expected an error")
+	}
+
+	// 1, 2, 4, 8 and 16ms delays fit in 50ms
+	if attempts > 10 {
+		t.Errorf("Retried %d times without growing the delay", attempts)
+	}
+}
+
+func TestPollSourceStartup(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache := filepath.Join(dir, "cache.yaml")
+
+	fail := func() ([]byte, error) { return nil, errors.New("unavailable") }
+
+	if _, err := PollSource(fail, "yaml", time.Hour, RequireSource, CacheFile(cache)); err == nil {
+		t.Errorf("Expected an error without a cache")
+	}
+
+	if err := ioutil.WriteFile(cache, []byte("cacheSection:\n  value: cached\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	stop, err := PollSource(fail, "yaml", time.Hour, RequireSource, CacheFile(cache))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer stop()
+
+	if got := GetString("cacheSection.value"); got != "cached" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "cached")
+	}
+}
+
+func TestPollSourceMaxAge(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache := filepath.Join(dir, "cache.yaml")
+	if err := ioutil.WriteFile(cache, []byte("staleSection:\n  value: cached\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(cache, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	fail := func() ([]byte, error) { return nil, errors.New("unavailable") }
+
+	if _, err := PollSource(fail, "yaml", time.Hour, CacheFile(cache), MaxAge(time.Hour), RefuseStale); !errors.Is(err, ErrStaleConfig) {
+		t.Errorf("Expected ErrStaleConfig: %v", err)
+	}
+
+	if got := GetString("staleSection.value"); got != "" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "")
+	}
+
+	stop, err := PollSource(fail, "yaml", time.Hour, CacheFile(cache), MaxAge(time.Hour))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer stop()
+
+	if got := GetString("staleSection.value"); got != "cached" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "cached")
+	}
+}
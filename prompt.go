@@ -0,0 +1,218 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// PromptStruct interactively asks for the values of a Struct, showing the current values as defaults.
+// Fields with a `choices:"a,b"` tag only accept one of the listed values.
+// Fields with a `secret:"true"` tag are read without echoing on terminals.
+func PromptStruct(in io.Reader, out io.Writer, rawVal interface{}) error {
+	rv := structValue(rawVal)
+	p := newPrompter(in, out)
+	for _, f := range structFields(rv.Type()) {
+		switch f.kind {
+		case reflect.Bool, reflect.String, reflect.Float64, reflect.Int:
+			if _, err := p.promptField(rv.Field(f.index), f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PromptMissing prompts for required fields that are still empty after the merge when stdin is a terminal
+func PromptMissing(o *BindOptions) { o.promptMissing = true }
+
+// resolveRequired checks that fields with a `required:"true"` tag have a value.
+// With PromptMissing, missing values are prompted for when the input is interactive.
+func resolveRequired(in io.Reader, out io.Writer, rawVal interface{}, opts *BindOptions) error {
+	rv := structValue(rawVal)
+	var p *prompter
+	for _, f := range structFields(rv.Type()) {
+		fv := rv.Field(f.index)
+		if !f.required || !fv.IsZero() {
+			continue
+		}
+		if !opts.promptMissing || !isInteractive(in) {
+			if f.argIndex >= 0 {
+				return &ErrValidation{Field: f.name, Arg: opts.argName(f), Rule: "required"}
+			}
+			return &ErrValidation{Field: f.name, Flag: opts.flagName(f.name), Rule: "required"}
+		}
+		if p == nil {
+			p = newPrompter(in, out)
+		}
+		// a zero value like false or 0 is a valid answer, only an empty answer is asked again
+		for answered := false; !answered; {
+			var err error
+			if answered, err = p.promptField(fv, f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isInteractive reports whether the input is a terminal.
+// Input that is not a file, like input set with cobra's SetIn, is considered interactive.
+func isInteractive(in io.Reader) bool {
+	if f, ok := in.(*os.File); ok {
+		return term.IsTerminal(int(f.Fd()))
+	}
+	return true
+}
+
+type prompter struct {
+	in  io.Reader
+	r   *bufio.Reader
+	out io.Writer
+}
+
+func newPrompter(in io.Reader, out io.Writer) *prompter {
+	return &prompter{in: in, r: bufio.NewReader(in), out: out}
+}
+
+// readLine reads a line of input, without echoing it on terminals when hidden
+func (p *prompter) readLine(hidden bool) (string, error) {
+	if f, ok := p.in.(*os.File); ok && hidden && term.IsTerminal(int(f.Fd())) {
+		b, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(p.out)
+		return string(b), err
+	}
+	line, err := p.r.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return "", io.ErrUnexpectedEOF
+	}
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return line, nil
+}
+
+// promptField asks for a field value until a valid value is given
+// and reports whether a value was typed instead of keeping the default
+func (p *prompter) promptField(fv reflect.Value, f fieldInfo) (bool, error) {
+	label := f.usage
+	if label == "" {
+		label = strcase.ToDelimited(f.name, ' ')
+	}
+	if len(f.choices) > 0 {
+		label += " (" + strings.Join(f.choices, "/") + ")"
+	}
+	for {
+		def := fmt.Sprintf("%v", fv.Interface())
+		if f.secret && def != "" {
+			fmt.Fprintf(p.out, "%s [hidden]: ", label)
+		} else {
+			fmt.Fprintf(p.out, "%s [%s]: ", label, def)
+		}
+		line, err := p.readLine(f.secret)
+		if err != nil {
+			return false, err
+		}
+		line = strings.TrimSpace(line)
+		answered := line != ""
+		if !answered {
+			line = def
+		}
+		if err := setFieldString(fv, line, f.choices); err != nil {
+			fmt.Fprintln(p.out, err)
+			continue
+		}
+		return answered, nil
+	}
+}
+
+// setFieldString parses the string into the field value
+func setFieldString(fv reflect.Value, s string, choices []string) error {
+	if len(choices) > 0 {
+		valid := false
+		for _, choice := range choices {
+			valid = valid || choice == s
+		}
+		if !valid {
+			return fmt.Errorf("invalid value %q, choose one of: %s", s, strings.Join(choices, ", "))
+		}
+	}
+	if fv.Type() == percentType {
+		p, err := ParsePercent(s)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(p))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		fv.SetBool(b)
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		fv.SetFloat(f)
+	case reflect.Int:
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		fv.SetInt(int64(i))
+	}
+	return nil
+}
+
+// ErrNotConfirmed is returned when a destructive operation is not confirmed
+var ErrNotConfirmed = errors.New("not confirmed")
+
+// AddConfirmFlags adds the --yes and --force flags that skip confirmation prompts
+func AddConfirmFlags(c *cobra.Command) {
+	c.Flags().BoolP("yes", "y", false, "Do not prompt for confirmation")
+	c.Flags().Bool("force", false, "Do not prompt for confirmation")
+}
+
+// Confirm asks the question and returns ErrNotConfirmed unless it is answered with yes.
+// The prompt is skipped when the --yes or --force flag is set.
+// Only terminals are prompted: non-interactive input, like a pipe in a script, confirms without a prompt.
+func Confirm(cmd *cobra.Command, question string) error {
+	for _, name := range []string{"yes", "force"} {
+		if yes, _ := cmd.Flags().GetBool(name); yes {
+			return nil
+		}
+	}
+	if !isInteractive(cmd.InOrStdin()) {
+		return nil
+	}
+	p := newPrompter(cmd.InOrStdin(), cmd.ErrOrStderr())
+	fmt.Fprintf(p.out, "%s [y/N]: ", question)
+	line, err := p.readLine(false)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return nil
+	}
+	return ErrNotConfirmed
+}
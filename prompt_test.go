@@ -0,0 +1,169 @@
+package cfg
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type promptStruct struct {
+	Name    string `usage:"Item name"`
+	Format  string `choices:"json,yaml"`
+	Retries int
+	Verbose bool
+}
+
+func TestPromptStruct(t *testing.T) {
+
+	config := promptStruct{
+		Name:    "default",
+		Retries: 3,
+	}
+
+	in := strings.NewReader("\nxml\nyaml\nmany\n5\ntrue\n")
+	out := new(bytes.Buffer)
+
+	if err := PromptStruct(in, out, &config); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	want := promptStruct{
+		Name:    "default",
+		Format:  "yaml",
+		Retries: 5,
+		Verbose: true,
+	}
+
+	if config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+
+	wantOut := "Item name [default]: " +
+		"format (json/yaml) []: invalid value \"xml\", choose one of: json, yaml\n" +
+		"format (json/yaml) []: " +
+		"retries [3]: invalid integer \"many\"\n" +
+		"retries [3]: " +
+		"verbose [false]: "
+
+	if out.String() != wantOut {
+		t.Errorf("\ngot:  %q\nwant: %q\n", out.String(), wantOut)
+	}
+
+	if err := PromptStruct(strings.NewReader(""), out, &config); err == nil {
+		t.Errorf("Expected error on end of input")
+	}
+}
+
+type requiredStruct struct {
+	User     string `required:"true"`
+	Password string `required:"true" secret:"true"`
+	Port     int
+}
+
+func TestRunBoundCommandRequired(t *testing.T) {
+
+	var (
+		config       requiredStruct
+		promptConfig requiredStruct
+	)
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	childCmd := &cobra.Command{
+		Use: "child",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	rootCmd.AddCommand(childCmd)
+
+	BindFlags(rootCmd, &config, NoViper)
+	BindFlags(childCmd, &promptConfig, NoViper, PromptMissing)
+
+	_, err := executeCommand(rootCmd, "--user", "admin")
+	if err == nil || err.Error() != "missing required value --password" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	rootCmd.SetIn(strings.NewReader("\nsecret\n"))
+
+	if _, err := executeCommand(rootCmd, "child", "--user", "admin"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if want := (requiredStruct{User: "admin", Password: "secret"}); promptConfig != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", promptConfig, want)
+	}
+}
+
+type requiredZeroStruct struct {
+	Enabled bool `required:"true"`
+	Retries int  `required:"true"`
+}
+
+func TestPromptRequiredZero(t *testing.T) {
+
+	var config requiredZeroStruct
+	var out bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		done <- resolveRequired(strings.NewReader("\nfalse\n0\n"), &out, &config, &BindOptions{promptMissing: true})
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Prompting for a zero value did not stop")
+	}
+
+	if want := (requiredZeroStruct{}); config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+
+	if got := strings.Count(out.String(), "enabled"); got != 2 {
+		t.Errorf("\ngot:  %v prompts\nwant: %v prompts\n", got, 2)
+	}
+}
+
+func TestConfirm(t *testing.T) {
+
+	cmd := &cobra.Command{Use: "root"}
+	AddConfirmFlags(cmd)
+	cmd.SetErr(new(bytes.Buffer))
+
+	cmd.SetIn(strings.NewReader("y\n"))
+	if err := Confirm(cmd, "Continue?"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	cmd.SetIn(strings.NewReader("\n"))
+	if err := Confirm(cmd, "Continue?"); !errors.Is(err, ErrNotConfirmed) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cmd.SetIn(f)
+	if err := Confirm(cmd, "Continue?"); err != nil {
+		t.Errorf("Unexpected error for non-interactive input: %v", err)
+	}
+
+	cmd.SetIn(strings.NewReader(""))
+	cmd.Flags().Set("yes", "true")
+	if err := Confirm(cmd, "Continue?"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
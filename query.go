@@ -0,0 +1,143 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// queryStep is a key, a list position or a field match in a query path
+type queryStep struct {
+	key   string
+	index int
+	field string
+	value string
+}
+
+// Query returns the value at the dot path, where lists can be indexed by position like
+// "collection[1].eighthParam" or by the value of a field like "collection[name=SecondItem].eighthParam".
+// Values keep the type they have in the config.
+func Query(path string) (interface{}, error) {
+	prefix, steps, err := parseQuery(path)
+	if err != nil {
+		return nil, err
+	}
+	loadConfig()
+	traceRead(prefix)
+	mu.RLock()
+	value := viper.Get(prefix)
+	mu.RUnlock()
+	for _, step := range steps {
+		if value == nil {
+			break
+		}
+		if value, err = step.apply(reflect.ValueOf(value)); err != nil {
+			return nil, fmt.Errorf("query %q: %w", path, err)
+		}
+	}
+	if value == nil {
+		mu.RLock()
+		defer mu.RUnlock()
+		return nil, notSetError(path)
+	}
+	return value, nil
+}
+
+// QueryAs returns the value at the query path decoded into T
+func QueryAs[T any](path string) (T, error) {
+	var v T
+	value, err := Query(path)
+	if err != nil {
+		return v, err
+	}
+	if err := decode(value, &v); err != nil {
+		return v, &ErrDecode{Key: path, Type: reflect.TypeOf(&v).Elem().String(), Value: value, Err: err}
+	}
+	return v, nil
+}
+
+// parseQuery splits the path into the dot path up to the first list selector and the remaining steps
+func parseQuery(path string) (string, []queryStep, error) {
+	i := strings.IndexByte(path, '[')
+	if i < 0 {
+		return path, nil, nil
+	}
+	prefix, rest := path[:i], path[i:]
+	var steps []queryStep
+	for rest != "" {
+		switch rest[0] {
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return "", nil, fmt.Errorf("query %q: missing ]", path)
+			}
+			sel := rest[1:end]
+			rest = rest[end+1:]
+			if field, value, ok := strings.Cut(sel, "="); ok {
+				steps = append(steps, queryStep{index: -1, field: field, value: value})
+				continue
+			}
+			index, err := strconv.Atoi(sel)
+			if err != nil || index < 0 {
+				return "", nil, fmt.Errorf("query %q: invalid index %q", path, sel)
+			}
+			steps = append(steps, queryStep{index: index})
+		case '.':
+			rest = rest[1:]
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			steps = append(steps, queryStep{index: -1, key: rest[:end]})
+			rest = rest[end:]
+		}
+	}
+	return prefix, steps, nil
+}
+
+// apply returns the value the step selects from the map or list, or nil when there is none
+func (s queryStep) apply(rv reflect.Value) (interface{}, error) {
+	if s.key != "" {
+		if rv.Kind() != reflect.Map {
+			return nil, fmt.Errorf("%s is not in a map", s.key)
+		}
+		return mapIndexFold(rv, s.key), nil
+	}
+	if rv.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("%s is not a list", rv.Type())
+	}
+	if s.field == "" {
+		if s.index >= rv.Len() {
+			return nil, fmt.Errorf("index %d out of range", s.index)
+		}
+		return rv.Index(s.index).Interface(), nil
+	}
+	for i := 0; i < rv.Len(); i++ {
+		item := reflect.ValueOf(rv.Index(i).Interface())
+		if item.Kind() != reflect.Map {
+			continue
+		}
+		if v := mapIndexFold(item, s.field); v != nil && fmt.Sprintf("%v", v) == s.value {
+			return item.Interface(), nil
+		}
+	}
+	return nil, nil
+}
+
+// mapIndexFold returns the value of the key in a map with string or interface keys, matching case-insensitively
+func mapIndexFold(rv reflect.Value, key string) interface{} {
+	for iter := rv.MapRange(); iter.Next(); {
+		if k, ok := iter.Key().Interface().(string); ok && strings.EqualFold(k, key) {
+			return iter.Value().Interface()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package cfg
+
+import (
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		{"firstParam", "First"},
+		{"collection[1].eighthParam", "SecondEighth"},
+		{"collection[name=ThirdItem].seventhParam", false},
+		{"collection[name=SecondItem].seventhParam", true},
+	}
+
+	for _, test := range tests {
+		got, err := Query(test.path)
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", test.path, err)
+		}
+		if got != test.want {
+			t.Errorf("%s\ngot:  %v\nwant: %v\n", test.path, got, test.want)
+		}
+	}
+
+	for _, path := range []string{"collection[5].name", "collection[name=Missing].name", "collection[x]", "collection[0"} {
+		if _, err := Query(path); err == nil {
+			t.Errorf("Expected an error for %s", path)
+		}
+	}
+
+	enabled, err := QueryAs[bool]("collection[name=SecondItem].seventhParam")
+	if err != nil || !enabled {
+		t.Errorf("\ngot:  %v %v\nwant: %v\n", enabled, err, true)
+	}
+}
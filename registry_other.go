@@ -0,0 +1,11 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !windows
+
+package cfg
+
+// MergeRegistry merges the Windows registry keys of the org and app into the loaded config.
+// It does nothing on platforms other than Windows.
+func MergeRegistry(org string, app string) error { return nil }
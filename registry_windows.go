@@ -0,0 +1,112 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build windows
+
+package cfg
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// MergeRegistry merges the registry keys Software\<org>\<app> of HKEY_LOCAL_MACHINE and HKEY_CURRENT_USER
+// into the loaded config like MergeMap, followed by the Group Policy keys under Software\Policies,
+// so the current user overrides the machine and policies override both, with machine policies last.
+// Subkeys are nested sections and value names are config keys. Missing keys are skipped.
+// On other platforms MergeRegistry does nothing.
+func MergeRegistry(org string, app string) error {
+	path := `Software\` + org + `\` + app
+	policy := `Software\Policies\` + org + `\` + app
+	layers := []struct {
+		root registry.Key
+		path string
+	}{
+		{registry.LOCAL_MACHINE, path},
+		{registry.CURRENT_USER, path},
+		{registry.CURRENT_USER, policy},
+		{registry.LOCAL_MACHINE, policy},
+	}
+	for _, l := range layers {
+		k, err := registry.OpenKey(l.root, l.path, registry.READ)
+		if errors.Is(err, registry.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		settings, err := registrySettings(k)
+		k.Close()
+		if err != nil {
+			return err
+		}
+		if err := MergeMap(settings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registrySettings reads the values and subkeys of the key as settings
+func registrySettings(k registry.Key) (map[string]interface{}, error) {
+	settings := make(map[string]interface{})
+	names, err := k.ReadValueNames(-1)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		value, err := registryValue(k, name)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			settings[strings.ToLower(name)] = value
+		}
+	}
+	subkeys, err := k.ReadSubKeyNames(-1)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range subkeys {
+		sub, err := registry.OpenKey(k, name, registry.READ)
+		if err != nil {
+			return nil, err
+		}
+		section, err := registrySettings(sub)
+		sub.Close()
+		if err != nil {
+			return nil, err
+		}
+		settings[strings.ToLower(name)] = section
+	}
+	return settings, nil
+}
+
+// registryValue reads a string, number or multi-string value. Other types are skipped.
+func registryValue(k registry.Key, name string) (interface{}, error) {
+	_, valtype, err := k.GetValue(name, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch valtype {
+	case registry.SZ:
+		s, _, err := k.GetStringValue(name)
+		return s, err
+	case registry.EXPAND_SZ:
+		s, _, err := k.GetStringValue(name)
+		if err != nil {
+			return nil, err
+		}
+		return registry.ExpandString(s)
+	case registry.DWORD, registry.QWORD:
+		n, _, err := k.GetIntegerValue(name)
+		return int(n), err
+	case registry.MULTI_SZ:
+		s, _, err := k.GetStringsValue(name)
+		return s, err
+	}
+	return nil, nil
+}
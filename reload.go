@@ -0,0 +1,116 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ReloadStatus describes the last Reload
+type ReloadStatus struct {
+	Time  time.Time `json:"time"`
+	Error string    `json:"error,omitempty"`
+}
+
+var lastReload struct {
+	sync.Mutex
+	status ReloadStatus
+}
+
+// LastReload returns the status of the last Reload, with a zero Time when the config wasn't reloaded
+func LastReload() ReloadStatus {
+	lastReload.Lock()
+	defer lastReload.Unlock()
+	return lastReload.status
+}
+
+// reloads counts the successful reloads, so sources apply their content again
+var reloads uint64
+
+var reloadHooks struct {
+	sync.Mutex
+	hooks []func()
+}
+
+// onReload registers a function that runs after the config is reloaded
+func onReload(f func()) {
+	reloadHooks.Lock()
+	defer reloadHooks.Unlock()
+	reloadHooks.hooks = append(reloadHooks.hooks, f)
+}
+
+// Reload reads the config file again and notifies the reload listeners, like Features.OnChange.
+// Aliases and migrations are applied again, and fragments merged with MergeReader, MergeMap and PollSource are kept.
+func Reload() error {
+	loadConfig()
+	start := time.Now()
+	end := startSpan("cfg.reload")
+	mu.Lock()
+	migrated, err := reloadConfig()
+	mu.Unlock()
+	if err == nil {
+		writeMigrations(migrated)
+		resolveExecValues()
+	}
+	end(err)
+	recordLoad(start, true, err)
+	resetCache()
+	status := ReloadStatus{Time: time.Now()}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	lastReload.Lock()
+	lastReload.status = status
+	lastReload.Unlock()
+	if err != nil {
+		return err
+	}
+	atomic.AddUint64(&reloads, 1)
+	reloaded()
+	return nil
+}
+
+// reloadConfig reads the settings of the config file again and applies the aliases and migrations.
+// The fragments and the values that were Set are kept. When no config file was found yet,
+// the config paths are searched again. Returns whether the settings were migrated.
+// The caller must hold the write lock.
+func reloadConfig() (bool, error) {
+	file := viper.ConfigFileUsed()
+	if file == "" {
+		err := viper.ReadInConfig()
+		// ReadInConfig replaced the config layer
+		rebuildConfig()
+		if err != nil {
+			return false, configError(err)
+		}
+		file = viper.ConfigFileUsed()
+	}
+	settings, err := readConfigFile(file)
+	if err != nil {
+		return false, err
+	}
+	settings, migrated, err := upgradeSettings(settings, file)
+	if err != nil {
+		return false, err
+	}
+	layers.file = settings
+	execRefs = nil
+	stampConfig()
+	return migrated, rebuildConfig()
+}
+
+// reloaded runs the reload hooks
+func reloaded() {
+	reloadHooks.Lock()
+	hooks := reloadHooks.hooks
+	reloadHooks.Unlock()
+	for _, f := range hooks {
+		f()
+	}
+}
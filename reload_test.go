@@ -0,0 +1,33 @@
+package cfg
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestReloadKeepsMigrationsAndFragments(t *testing.T) {
+
+	defer func(w io.Writer) { WarningOutput = w }(WarningOutput)
+	WarningOutput = new(bytes.Buffer)
+
+	useConfigFile(t, "reloadOld: Migrated\nreloadAlias: Aliased\n")
+	RegisterMigration(0, 1, func(settings map[string]interface{}) error {
+		settings["reloadnew"] = settings["reloadold"]
+		return nil
+	})
+	RegisterAlias("reloadAlias", "reloadRenamed")
+	if err := MergeMap(map[string]interface{}{"reloadFragment": "Merged"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := Reload(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for key, want := range map[string]string{"reloadNew": "Migrated", VersionKey: "1", "reloadRenamed": "Aliased", "reloadFragment": "Merged"} {
+		if got := GetString(key); got != want {
+			t.Errorf("%s\ngot:  %v\nwant: %v\n", key, got, want)
+		}
+	}
+}
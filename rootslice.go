@@ -0,0 +1,50 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// UnmarshalRootSlice decodes a config file whose root is a list, like an inventory, into a slice.
+// Viper only reads config files with a map at the root, so the file is read separately.
+// Only yaml and json files are supported.
+func UnmarshalRootSlice(rawVal interface{}, opts ...viper.DecoderConfigOption) error {
+	loadConfig()
+	mu.RLock()
+	file := viper.ConfigFileUsed()
+	mu.RUnlock()
+	if file == "" {
+		return ErrConfigNotFound
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return configError(err)
+	}
+	var items []interface{}
+	switch ext := strings.ToLower(filepath.Ext(file)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &items)
+	case ".json":
+		err = json.Unmarshal(b, &items)
+	default:
+		return fmt.Errorf("unsupported config type %q for a list root", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", file, err)
+	}
+	if err := decode(items, rawVal, opts...); err != nil {
+		return &ErrDecode{Type: reflect.TypeOf(rawVal).Elem().String(), Value: items, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+type inventoryHost struct {
+	Name string
+	Port int
+}
+
+func TestUnmarshalRootSlice(t *testing.T) {
+
+	Reset()
+	defer Reset()
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "inventory.yaml")
+	if err := ioutil.WriteFile(file, []byte("- name: web\n  port: 80\n- name: db\n  port: 5432\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ReadInConfig()
+	viper.SetConfigFile(file)
+
+	var hosts []inventoryHost
+	if err := UnmarshalKey("", &hosts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []inventoryHost{{Name: "web", Port: 80}, {Name: "db", Port: 5432}}
+	if !reflect.DeepEqual(hosts, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", hosts, want)
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretResolver returns the secret a reference like "vault:secret/db#password" points to
+type SecretResolver func(ctx context.Context, ref string) (string, error)
+
+// SecretLease is a secret with the metadata of remote secret stores that rotate secrets
+type SecretLease struct {
+	Value string
+	// Version identifies the secret, like a Vault lease ID or a secret manager version
+	Version string
+	// Expires is when the secret must be resolved again, zero when it doesn't expire
+	Expires time.Time
+}
+
+// LeaseResolver returns the secret a reference points to with its lease
+type LeaseResolver func(ctx context.Context, ref string) (SecretLease, error)
+
+// RefreshBefore is how long before a lease expires a Secret with OnRotate callbacks is resolved again
+var RefreshBefore = time.Minute
+
+// MinRefreshInterval is the shortest wait before a lease is resolved again,
+// so leases that are shorter than RefreshBefore or already expired aren't resolved in a loop
+var MinRefreshInterval = time.Second
+
+var secretResolvers struct {
+	sync.Mutex
+	byPrefix map[string]LeaseResolver
+}
+
+// RegisterSecretResolver registers the resolver of Secret values starting with the prefix, like "vault:".
+// Values starting with "!exec " run the command like !exec config values.
+func RegisterSecretResolver(prefix string, r SecretResolver) {
+	RegisterLeaseResolver(prefix, func(ctx context.Context, ref string) (SecretLease, error) {
+		value, err := r(ctx, ref)
+		return SecretLease{Value: value}, err
+	})
+}
+
+// RegisterLeaseResolver registers the resolver of Secret values starting with the prefix
+// for secret stores that return leases, so Secrets are resolved again before they expire
+func RegisterLeaseResolver(prefix string, r LeaseResolver) {
+	secretResolvers.Lock()
+	defer secretResolvers.Unlock()
+	if secretResolvers.byPrefix == nil {
+		secretResolvers.byPrefix = make(map[string]LeaseResolver)
+	}
+	secretResolvers.byPrefix[prefix] = r
+}
+
+// findResolver returns the resolver of the reference, or nil when it is a plain value
+func findResolver(ref string) LeaseResolver {
+	if strings.HasPrefix(ref, execPrefix) {
+		return func(ctx context.Context, ref string) (SecretLease, error) {
+			value, err := runExec(ctx, strings.TrimPrefix(ref, execPrefix))
+			return SecretLease{Value: value}, err
+		}
+	}
+	secretResolvers.Lock()
+	defer secretResolvers.Unlock()
+	longest := ""
+	for prefix := range secretResolvers.byPrefix {
+		if strings.HasPrefix(ref, prefix) && len(prefix) > len(longest) {
+			longest = prefix
+		}
+	}
+	return secretResolvers.byPrefix[longest]
+}
+
+// Secret is a config value that is resolved when it is first needed instead of when the config is loaded,
+// so commands that don't use it don't run password managers or authenticate with secret stores.
+// The value is a reference handled by a SecretResolver or a plain secret. Secret fields are always redacted.
+type Secret struct {
+	s *lazySecret
+}
+
+type lazySecret struct {
+	mu       sync.Mutex
+	ref      string
+	lease    SecretLease
+	resolved bool
+	onRotate map[int]func(SecretLease)
+	nextID   int
+	timer    *time.Timer
+}
+
+var secretType = reflect.TypeOf(Secret{})
+
+// NewSecret returns a Secret for the reference
+func NewSecret(ref string) Secret {
+	return Secret{s: &lazySecret{ref: ref}}
+}
+
+// IsSet reports whether the Secret has a value
+func (s Secret) IsSet() bool {
+	return s.s != nil && s.s.ref != ""
+}
+
+// Resolve returns the secret, resolving the reference on first use and when its lease expired,
+// which calls the OnRotate functions when the secret changed. Failures are not cached, so the next call tries again.
+func (s Secret) Resolve(ctx context.Context) (string, error) {
+	if s.s == nil {
+		return "", nil
+	}
+	s.s.mu.Lock()
+	if s.s.resolved && (s.s.lease.Expires.IsZero() || time.Now().Before(s.s.lease.Expires)) {
+		defer s.s.mu.Unlock()
+		return s.s.lease.Value, nil
+	}
+	changed, err := s.s.resolve(ctx)
+	lease, callbacks := s.s.lease, s.s.callbacks(changed)
+	s.s.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	for _, f := range callbacks {
+		f(lease)
+	}
+	return lease.Value, nil
+}
+
+// Lease returns the value and metadata of the secret when it was last resolved, or a zero lease
+func (s Secret) Lease() SecretLease {
+	if s.s == nil {
+		return SecretLease{}
+	}
+	s.s.mu.Lock()
+	defer s.s.mu.Unlock()
+	return s.s.lease
+}
+
+// OnRotate registers a function that is called with the new lease when the secret changed,
+// like to rebuild a database pool. While functions are registered, secrets with an expiring lease are
+// resolved again RefreshBefore they expire, or halfway when the lease is shorter, but not sooner than
+// MinRefreshInterval. Call the returned function to unregister.
+func (s Secret) OnRotate(f func(SecretLease)) (cancel func()) {
+	if s.s == nil {
+		return func() {}
+	}
+	s.s.mu.Lock()
+	defer s.s.mu.Unlock()
+	if s.s.onRotate == nil {
+		s.s.onRotate = make(map[int]func(SecretLease))
+	}
+	id := s.s.nextID
+	s.s.nextID++
+	s.s.onRotate[id] = f
+	s.s.schedule()
+	return func() {
+		s.s.mu.Lock()
+		defer s.s.mu.Unlock()
+		delete(s.s.onRotate, id)
+		if len(s.s.onRotate) == 0 && s.s.timer != nil {
+			s.s.timer.Stop()
+			s.s.timer = nil
+		}
+	}
+}
+
+// resolve resolves the reference and reports whether the value or version changed.
+// The caller must hold the lock.
+func (s *lazySecret) resolve(ctx context.Context) (bool, error) {
+	lease := SecretLease{Value: s.ref}
+	if r := findResolver(s.ref); r != nil {
+		var err error
+		if lease, err = r(ctx, s.ref); err != nil {
+			return false, err
+		}
+	}
+	changed := s.resolved && (lease.Value != s.lease.Value || lease.Version != s.lease.Version)
+	s.lease, s.resolved = lease, true
+	s.schedule()
+	return changed, nil
+}
+
+// callbacks returns the OnRotate functions to call when the lease changed. The caller must hold the lock.
+func (s *lazySecret) callbacks(changed bool) []func(SecretLease) {
+	if !changed {
+		return nil
+	}
+	var callbacks []func(SecretLease)
+	for _, f := range s.onRotate {
+		callbacks = append(callbacks, f)
+	}
+	return callbacks
+}
+
+// schedule starts the timer refreshing the lease before it expires when OnRotate functions are registered.
+// The caller must hold the lock.
+func (s *lazySecret) schedule() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if !s.resolved || s.lease.Expires.IsZero() || len(s.onRotate) == 0 {
+		return
+	}
+	ttl := time.Until(s.lease.Expires)
+	wait := ttl - RefreshBefore
+	if wait < ttl/2 {
+		// a lease shorter than RefreshBefore is resolved again halfway
+		wait = ttl / 2
+	}
+	if wait < MinRefreshInterval {
+		wait = MinRefreshInterval
+	}
+	s.timer = time.AfterFunc(wait, s.refresh)
+}
+
+// refresh resolves the lease again and calls the OnRotate functions when it changed
+func (s *lazySecret) refresh() {
+	s.mu.Lock()
+	changed, err := s.resolve(context.Background())
+	if err != nil {
+		fmt.Fprintf(WarningOutput, "Warning: refreshing secret: %s\n", err)
+		if remaining := time.Until(s.lease.Expires); remaining > 0 && len(s.onRotate) > 0 {
+			s.timer = time.AfterFunc(remaining/2, s.refresh)
+		}
+	}
+	lease, callbacks := s.lease, s.callbacks(changed)
+	s.mu.Unlock()
+	for _, f := range callbacks {
+		f(lease)
+	}
+}
+
+// String returns Redacted when the Secret is set, so it isn't printed by accident
+func (s Secret) String() string {
+	if !s.IsSet() {
+		return ""
+	}
+	return Redacted
+}
+
+// Set sets the reference, so *Secret can be used as a flag value
+func (s *Secret) Set(ref string) error {
+	*s = NewSecret(ref)
+	return nil
+}
+
+func (s *Secret) Type() string { return "secret" }
+
+// secretHook decodes strings into a Secret
+func secretHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if to != secretType || from.Kind() != reflect.String {
+		return data, nil
+	}
+	return NewSecret(data.(string)), nil
+}
+
+// lazySecretKey reports whether the key is bound to a Secret field
+func lazySecretKey(key string) bool {
+	schemaKeys.Lock()
+	defer schemaKeys.Unlock()
+	return schemaKeys.lazy[key]
+}
@@ -0,0 +1,161 @@
+package cfg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type secretStruct struct {
+	Password Secret
+	Token    Secret
+	Plain    Secret
+}
+
+func TestLazySecret(t *testing.T) {
+
+	ExecCommands = []string{"echo"}
+	defer func() { ExecCommands = nil }()
+
+	calls := 0
+	RegisterSecretResolver("test:", func(ctx context.Context, ref string) (string, error) {
+		calls++
+		if ref == "test:fail" {
+			return "", errors.New("unavailable")
+		}
+		return "resolved " + ref, nil
+	})
+
+	viper.Set("secretSection.password", "!exec echo lazy")
+	viper.Set("secretSection.token", "test:token")
+	viper.Set("secretSection.plain", "plain")
+
+	var config secretStruct
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, Key("secretSection"))
+
+	if _, err := executeCommand(rootCmd); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if calls != 0 {
+		t.Errorf("Expected no secrets to be resolved before use")
+	}
+
+	if got := fmt.Sprint(config.Password); got != Redacted {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, Redacted)
+	}
+
+	ctx := context.Background()
+	for _, test := range []struct {
+		secret Secret
+		want   string
+	}{
+		{config.Password, "lazy"},
+		{config.Token, "resolved test:token"},
+		{config.Token, "resolved test:token"},
+		{config.Plain, "plain"},
+	} {
+		got, err := test.secret.Resolve(ctx)
+		if err != nil || got != test.want {
+			t.Errorf("\ngot:  %v, %v\nwant: %v\n", got, err, test.want)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("\ngot:  %v calls\nwant: %v calls\n", calls, 1)
+	}
+
+	if _, err := NewSecret("test:fail").Resolve(ctx); err == nil {
+		t.Errorf("Expected an error")
+	}
+
+	if !isRedacted("secretsection.plain") {
+		t.Errorf("Expected Secret fields to be redacted")
+	}
+}
+
+func TestSecretRotation(t *testing.T) {
+
+	RefreshBefore, MinRefreshInterval = 50*time.Millisecond, 10*time.Millisecond
+	defer func() { RefreshBefore, MinRefreshInterval = time.Minute, time.Second }()
+
+	var mu sync.Mutex
+	version := 0
+	RegisterLeaseResolver("rotating:", func(ctx context.Context, ref string) (SecretLease, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		version++
+		return SecretLease{
+			Value:   fmt.Sprintf("password-%d", version),
+			Version: fmt.Sprint(version),
+			Expires: time.Now().Add(60 * time.Millisecond),
+		}, nil
+	})
+
+	secret := NewSecret("rotating:db")
+	rotated := make(chan SecretLease, 10)
+	cancel := secret.OnRotate(func(lease SecretLease) { rotated <- lease })
+
+	got, err := secret.Resolve(context.Background())
+	if err != nil || got != "password-1" {
+		t.Fatalf("\ngot:  %v, %v\nwant: %v\n", got, err, "password-1")
+	}
+
+	select {
+	case lease := <-rotated:
+		if lease.Value != "password-2" || lease.Version != "2" {
+			t.Errorf("\ngot:  %v\nwant: %v\n", lease.Value, "password-2")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the secret to rotate")
+	}
+
+	cancel()
+
+	if lease := secret.Lease(); lease.Version == "1" || lease.Expires.IsZero() {
+		t.Errorf("Expected the lease of a rotated secret: %v", lease)
+	}
+}
+
+func TestSecretShortLease(t *testing.T) {
+
+	MinRefreshInterval = 50 * time.Millisecond
+	defer func() { MinRefreshInterval = time.Second }()
+
+	var mu sync.Mutex
+	resolved := 0
+	RegisterLeaseResolver("short:", func(ctx context.Context, ref string) (SecretLease, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		resolved++
+		// shorter than RefreshBefore and expired after the first refresh
+		return SecretLease{Value: "password", Version: fmt.Sprint(resolved), Expires: time.Now().Add(time.Millisecond)}, nil
+	})
+
+	secret := NewSecret("short:db")
+	cancel := secret.OnRotate(func(SecretLease) {})
+	if _, err := secret.Resolve(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	time.Sleep(240 * time.Millisecond)
+	cancel()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if resolved < 2 || resolved > 6 {
+		t.Errorf("\ngot:  %v resolves\nwant: about %v\n", resolved, 5)
+	}
+}
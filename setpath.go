@@ -0,0 +1,133 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SetPath sets the value at a query path like "servers[0].name" or "servers[name=prod].port" in the settings
+// of the config file instead of as an override, so Write keeps the nesting of the document.
+// A value that was Set for the path still takes precedence.
+// Missing maps are created, and list items are appended when the index is the length of the list
+// or no item matches the field.
+func SetPath(path string, value interface{}) error {
+	steps, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	loadConfig()
+	mu.Lock()
+	defer resetCache()
+	defer mu.Unlock()
+	root, err := setStep(deepCopy(layers.file), steps, value)
+	if err != nil {
+		return fmt.Errorf("set %q: %w", path, err)
+	}
+	layers.file = root.(map[string]interface{})
+	return rebuildConfig()
+}
+
+// parsePath parses a query path into steps, splitting the dot path before the first list selector
+func parsePath(path string) ([]queryStep, error) {
+	prefix, steps, err := parseQuery(path)
+	if err != nil {
+		return nil, err
+	}
+	var keys []queryStep
+	for _, key := range strings.Split(prefix, ".") {
+		if key == "" {
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+		keys = append(keys, queryStep{index: -1, key: key})
+	}
+	return append(keys, steps...), nil
+}
+
+// setStep returns the container with the value set at the steps
+func setStep(container interface{}, steps []queryStep, value interface{}) (interface{}, error) {
+	if len(steps) == 0 {
+		return value, nil
+	}
+	s := steps[0]
+	if s.key != "" {
+		m, ok := toStringMap(container)
+		if container == nil {
+			m, ok = make(map[string]interface{}), true
+		}
+		if !ok {
+			return nil, fmt.Errorf("%s is not in a map", s.key)
+		}
+		key := strings.ToLower(s.key)
+		for k := range m {
+			if strings.EqualFold(k, s.key) {
+				key = k
+			}
+		}
+		child, err := setStep(m[key], steps[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = child
+		return m, nil
+	}
+	list, ok := toList(container)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a list", container)
+	}
+	i := s.index
+	if s.field != "" {
+		i = len(list)
+		for j, item := range list {
+			if m, ok := toStringMap(item); ok {
+				if v := lookupFold(m, s.field); v != nil && fmt.Sprintf("%v", v) == s.value {
+					i = j
+					break
+				}
+			}
+		}
+		if i == len(list) {
+			list = append(list, map[string]interface{}{s.field: s.value})
+		}
+	} else if i == len(list) {
+		list = append(list, nil)
+	} else if i > len(list) {
+		return nil, fmt.Errorf("index %d out of range", i)
+	}
+	child, err := setStep(list[i], steps[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	list[i] = child
+	return list, nil
+}
+
+// toList returns the config list as a []interface{}, or an empty list for nil
+func toList(v interface{}) ([]interface{}, bool) {
+	switch l := v.(type) {
+	case nil:
+		return nil, true
+	case []interface{}:
+		return l, true
+	case []map[string]interface{}:
+		list := make([]interface{}, len(l))
+		for i, item := range l {
+			list[i] = item
+		}
+		return list, true
+	}
+	return nil, false
+}
+
+// lookupFold returns the value of the key matching case-insensitively
+func lookupFold(m map[string]interface{}, key string) interface{} {
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return nil
+}
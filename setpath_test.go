@@ -0,0 +1,105 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+func TestSetPath(t *testing.T) {
+
+	Reset()
+	defer Reset()
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(file, []byte("servers:\n- name: prod\n  port: 80\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ReadInConfig()
+	viper.SetConfigFile(file)
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, set := range []struct {
+		path  string
+		value interface{}
+	}{
+		{"servers[0].port", 8080},
+		{"servers[name=dev].port", 9090},
+		{"nested.section.enabled", true},
+		{"servers[name=prod].name", "production"},
+	} {
+		if err := SetPath(set.path, set.value); err != nil {
+			t.Fatalf("Unexpected error for %s: %v", set.path, err)
+		}
+	}
+
+	if err := SetPath("servers[5].name", "x"); err == nil {
+		t.Errorf("Expected an error for an index out of range")
+	}
+
+	if err := Write(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := yaml.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"servers": []interface{}{
+			map[interface{}]interface{}{"name": "production", "port": 8080},
+			map[interface{}]interface{}{"name": "dev", "port": 9090},
+		},
+		"nested": map[interface{}]interface{}{
+			"section": map[interface{}]interface{}{"enabled": true},
+		},
+	}
+	for key := range want {
+		if !reflect.DeepEqual(got[key], want[key]) {
+			t.Errorf("%s\ngot:  %v\nwant: %v\n", key, got[key], want[key])
+		}
+	}
+}
+
+func TestSetPathKeepsEnv(t *testing.T) {
+
+	useConfigFile(t, "setPathSection:\n  port: 80\n")
+	t.Setenv("SETPATHENV", "Env")
+	viper.AutomaticEnv()
+	SetDefault("setPathEnv", "")
+
+	if err := SetPath("setPathSection.port", 8080); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := GetString("setPathEnv"); got != "Env" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "Env")
+	}
+
+	if got := GetInt("setPathSection.port"); got != 8080 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, 8080)
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	if viper.InConfig("setPathEnv") {
+		t.Errorf("Unexpected config value for setPathEnv")
+	}
+}
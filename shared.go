@@ -0,0 +1,48 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// NewSharedConfig returns a Config for the section of the app in a config file shared by several programs,
+// like a ~/.companyrc with a section per tool. Write only replaces the section of the app,
+// keeping the sections other programs wrote in the meantime. Bind Structs to it with UseConfig.
+func NewSharedConfig(file string, app string) *Config {
+	return &Config{base: NewConfig(file), ns: strings.ToLower(app), shared: true}
+}
+
+// writeSection writes the section of the shared Config to the file, reading the other sections from the file
+func (c *Config) writeSection() error {
+	base := c.base
+	base.load()
+	unlock, err := lockConfig(base.file)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	base.mu.Lock()
+	defer base.mu.Unlock()
+	current := viper.New()
+	current.SetConfigFile(base.file)
+	if err := current.ReadInConfig(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	settings := current.AllSettings()
+	if section, ok := base.v.AllSettings()[c.ns]; ok {
+		settings[c.ns] = deepCopy(section)
+	} else {
+		delete(settings, c.ns)
+	}
+	if err := writeAtomic(base.file, settings); err != nil {
+		return configError(err)
+	}
+	return base.v.ReadInConfig()
+}
@@ -0,0 +1,80 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type sharedStruct struct {
+	Port int
+	Name string
+}
+
+func TestSharedConfig(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "companyrc.yaml")
+	content := "name: root\ntoola:\n  port: 80\ntoolb:\n  name: second\n"
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	toolA := NewSharedConfig(file, "toolA")
+	toolB := NewSharedConfig(file, "toolB")
+
+	var config sharedStruct
+
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	BindFlags(rootCmd, &config, UseConfig(toolA))
+
+	if _, err := executeCommand(rootCmd); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if want := (sharedStruct{Port: 80}); config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+
+	// both tools loaded the file before writing their sections
+	toolA.Set("port", 8080)
+	toolB.Set("name", "updated")
+
+	if err := toolB.Write(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := toolA.Write(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := v.GetInt("toola.port"); got != 8080 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, 8080)
+	}
+
+	if got := v.GetString("toolb.name"); got != "updated" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "updated")
+	}
+
+	if got := v.GetString("name"); got != "root" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "root")
+	}
+}
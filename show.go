@@ -0,0 +1,178 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v2"
+)
+
+// DumpStyle is the output style of DumpRedacted
+type DumpStyle string
+
+const (
+	// DumpYAML writes YAML with the keys of bound Structs in the order of their fields and other keys sorted
+	DumpYAML DumpStyle = "yaml"
+	// DumpSortedYAML writes YAML with all keys sorted
+	DumpSortedYAML DumpStyle = "sorted"
+	// DumpJSON writes indented JSON with sorted keys
+	DumpJSON DumpStyle = "json"
+	// DumpMinifiedJSON writes JSON on a single line
+	DumpMinifiedJSON DumpStyle = "json-min"
+	// DumpFlat writes sorted key=value lines with dot-separated keys
+	DumpFlat DumpStyle = "flat"
+)
+
+// dumpStyles are the styles accepted by config show --output
+var dumpStyles = []DumpStyle{DumpYAML, DumpSortedYAML, DumpJSON, DumpMinifiedJSON, DumpFlat}
+
+// DumpRedacted writes the effective config in the style with secrets redacted like Handler
+func DumpRedacted(w io.Writer, style DumpStyle) error {
+	return dumpSettings(w, effectiveConfig().Config, style)
+}
+
+// dumpSettings writes the settings in the style
+func dumpSettings(w io.Writer, settings map[string]interface{}, style DumpStyle) error {
+	switch style {
+	case DumpYAML, "":
+		b, err := yaml.Marshal(canonical(settings, ""))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	case DumpSortedYAML:
+		b, err := yaml.Marshal(settings)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	case DumpJSON, DumpMinifiedJSON:
+		enc := json.NewEncoder(w)
+		if style == DumpJSON {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(settings)
+	case DumpFlat:
+		flat := make(map[string]interface{})
+		flattenSettings(settings, "", flat)
+		keys := make([]string, 0, len(flat))
+		for key := range flat {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if _, err := fmt.Fprintf(w, "%s=%s\n", key, flatValue(flat[key])); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown output style %q, use one of: %s", style, joinStyles(dumpStyles))
+}
+
+// flatValue formats a value for key=value lines, with lists and maps as JSON
+func flatValue(v interface{}) string {
+	switch v.(type) {
+	case []interface{}, map[string]interface{}:
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func joinStyles(styles []DumpStyle) string {
+	names := make([]string, len(styles))
+	for i, s := range styles {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ", ")
+}
+
+// ANSI colors of the defaults diff
+const (
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorReset  = "\x1b[0m"
+)
+
+// writeDefaultsDiff writes the keys of the effective config that differ from the registered defaults,
+// prefixed with + when there is no default and ~ when the default changed, in green and yellow with color
+func writeDefaultsDiff(w io.Writer, color bool) error {
+	flat := make(map[string]interface{})
+	flattenSettings(effectiveConfig().Config, "", flat)
+	defaults := make(map[string]interface{})
+	flattenSettings(Defaults(), "", defaults)
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		def, ok := defaults[key]
+		if ok {
+			def = redact(key, def)
+		}
+		if ok && jsonEqual(flat[key], def) {
+			continue
+		}
+		line, c := fmt.Sprintf("+ %s=%s", key, flatValue(flat[key])), colorGreen
+		if ok {
+			line, c = fmt.Sprintf("~ %s=%s (default %s)", key, flatValue(flat[key]), flatValue(def)), colorYellow
+		}
+		if color {
+			line = c + line + colorReset
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// useColor reports whether the output is a terminal and NO_COLOR is not set
+func useColor(w io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// newShowCommand creates the config show command printing the effective config
+func newShowCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "show",
+		Short: "Print the effective config with secrets redacted",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if sources, _ := cmd.Flags().GetBool("sources"); sources {
+				return DumpSources(cmd.OutOrStdout())
+			}
+			if diff, _ := cmd.Flags().GetBool("diff-defaults"); diff {
+				return writeDefaultsDiff(cmd.OutOrStdout(), useColor(cmd.OutOrStdout()))
+			}
+			output, _ := cmd.Flags().GetString("output")
+			return DumpRedacted(cmd.OutOrStdout(), DumpStyle(output))
+		},
+	}
+	c.Flags().StringP("output", "o", string(DumpYAML), "Output style: "+joinStyles(dumpStyles))
+	c.Flags().Bool("sources", false, "Annotate each key with its source and the values it won from")
+	c.Flags().Bool("diff-defaults", false, "Only print the keys that differ from the defaults: + without a default, ~ changed")
+	_ = c.RegisterFlagCompletionFunc("output", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return strings.Split(joinStyles(dumpStyles), ", "), cobra.ShellCompDirectiveNoFileComp
+	})
+	return c
+}
@@ -0,0 +1,85 @@
+package cfg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDumpStyles(t *testing.T) {
+
+	settings := map[string]interface{}{
+		"b":      map[string]interface{}{"port": 80, "hosts": []interface{}{"x", "y"}},
+		"a":      "first",
+		"secret": Redacted,
+	}
+
+	tests := []struct {
+		style DumpStyle
+		want  string
+	}{
+		{DumpSortedYAML, "a: first\nb:\n  hosts:\n  - x\n  - \"y\"\n  port: 80\nsecret: '[redacted]'\n"},
+		{DumpMinifiedJSON, `{"a":"first","b":{"hosts":["x","y"],"port":80},"secret":"[redacted]"}` + "\n"},
+		{DumpFlat, "a=first\nb.hosts=[\"x\",\"y\"]\nb.port=80\nsecret=[redacted]\n"},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := dumpSettings(&buf, settings, test.style); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("%s\ngot:  %q\nwant: %q\n", test.style, got, test.want)
+		}
+	}
+
+	if err := dumpSettings(&bytes.Buffer{}, settings, "xml"); err == nil {
+		t.Errorf("Expected an error for an unknown style")
+	}
+}
+
+func TestConfigShowCommand(t *testing.T) {
+
+	Set("showSection.apiToken", "hidden")
+	Set("showSection.name", "visible")
+
+	output, err := executeCommand(NewConfigCommand(), "show", "--output", "flat")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(output, "showsection.name=visible\n") || !strings.Contains(output, "showsection.apitoken=[redacted]\n") {
+		t.Errorf("Unexpected output:\n%s", output)
+	}
+}
+
+func TestConfigShowDiffDefaults(t *testing.T) {
+
+	Reset()
+	defer Reset()
+
+	SetDefault("diffSection.port", 80)
+	SetDefault("diffSection.host", "localhost")
+	Set("diffSection.port", 8080)
+	Set("diffSection.extra", "added")
+
+	var buf bytes.Buffer
+	if err := writeDefaultsDiff(&buf, true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := colorGreen + "+ diffsection.extra=added" + colorReset + "\n" +
+		colorYellow + "~ diffsection.port=8080 (default 80)" + colorReset + "\n"
+	if got := buf.String(); !strings.Contains(got, want) || strings.Contains(got, "diffsection.host") {
+		t.Errorf("\ngot:  %q\nwant: %q\n", got, want)
+	}
+
+	output, err := executeCommand(NewConfigCommand(), "show", "--diff-defaults")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(output, "~ diffsection.port=8080 (default 80)\n") || strings.Contains(output, colorReset) {
+		t.Errorf("Unexpected output:\n%q", output)
+	}
+}
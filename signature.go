@@ -0,0 +1,127 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrSignature is returned when the config signature is missing or doesn't match
+var ErrSignature = errors.New("invalid config signature")
+
+// ErrSignedConfig is returned when writing the config file while a verify key is set,
+// as the written file would fail verification. Sign the edited file outside the program instead.
+var ErrSignedConfig = errors.New("config file is signed")
+
+// verifyKey is the public key config files are verified with. Guarded by mu.
+var verifyKey struct {
+	key ed25519.PublicKey
+	id  []byte
+}
+
+// SetVerifyKey requires the config file to have a detached Ed25519 signature in a .sig file next to it,
+// verified with the base64 encoded public key. The key can be a raw key or a minisign public key.
+// A config file that fails verification is not applied: loading the config panics and Reload returns the error.
+// Write, Tx.Commit and config migrate return ErrSignedConfig instead of writing the config file while a key is set.
+// An empty key disables verification.
+func SetVerifyKey(key string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	verifyKey.key, verifyKey.id = nil, nil
+	if key == "" {
+		return nil
+	}
+	b, err := base64.StdEncoding.DecodeString(lastLine(key))
+	if err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+	switch {
+	case len(b) == ed25519.PublicKeySize:
+		verifyKey.key = b
+	case len(b) == 10+ed25519.PublicKeySize && string(b[:2]) == "Ed":
+		verifyKey.key, verifyKey.id = b[10:], b[2:10]
+	default:
+		return errors.New("invalid public key: expected an Ed25519 or minisign public key")
+	}
+	return nil
+}
+
+// VerifySignature verifies the detached signature of the data with the key set with SetVerifyKey.
+// The signature is a base64 encoded Ed25519 signature or a minisign signature file with the legacy algorithm.
+// Without a key no signature is valid.
+func VerifySignature(data []byte, sig []byte) error {
+	mu.RLock()
+	defer mu.RUnlock()
+	return verifySignature(data, sig)
+}
+
+// verifySignature verifies the signature. The caller must hold the lock.
+func verifySignature(data []byte, sig []byte) error {
+	if verifyKey.key == nil {
+		return fmt.Errorf("%w: no verify key is set", ErrSignature)
+	}
+	b, err := base64.StdEncoding.DecodeString(signatureLine(string(sig)))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	switch {
+	case len(b) == ed25519.SignatureSize:
+	case len(b) == 10+ed25519.SignatureSize && string(b[:2]) == "Ed":
+		if verifyKey.id != nil && !bytes.Equal(b[2:10], verifyKey.id) {
+			return fmt.Errorf("%w: signed with another key", ErrSignature)
+		}
+		b = b[10:]
+	case len(b) == 10+ed25519.SignatureSize && string(b[:2]) == "ED":
+		return fmt.Errorf("%w: prehashed minisign signatures are not supported, sign with minisign -l", ErrSignature)
+	default:
+		return ErrSignature
+	}
+	if !ed25519.Verify(verifyKey.key, data, b) {
+		return ErrSignature
+	}
+	return nil
+}
+
+// verifyConfigData verifies the signature of the data read from the config file when a key is set.
+// The caller must hold the lock.
+func verifyConfigData(file string, data []byte) error {
+	if verifyKey.key == nil {
+		return nil
+	}
+	sig, err := os.ReadFile(file + ".sig")
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	return verifySignature(data, sig)
+}
+
+// checkUnsigned returns ErrSignedConfig when config files are verified. The caller must hold the lock.
+func checkUnsigned(file string) error {
+	if verifyKey.key != nil {
+		return fmt.Errorf("%w: writing %s would invalidate its signature", ErrSignedConfig, file)
+	}
+	return nil
+}
+
+// signatureLine returns the signature line of a minisign signature file or the signature itself
+func signatureLine(sig string) string {
+	lines := strings.Split(strings.TrimSpace(sig), "\n")
+	if len(lines) > 1 && strings.HasPrefix(lines[0], "untrusted comment:") {
+		return strings.TrimSpace(lines[1])
+	}
+	return strings.TrimSpace(lines[0])
+}
+
+// lastLine returns the last line of a minisign public key file or the key itself
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
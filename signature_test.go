@@ -0,0 +1,178 @@
+package cfg
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestSignedConfig(t *testing.T) {
+
+	Reset()
+	defer Reset()
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetVerifyKey(base64.StdEncoding.EncodeToString(pub)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer SetVerifyKey("")
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	write := func(content string, signed string) {
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(signed)))
+		if err := ioutil.WriteFile(file+".sig", []byte(sig+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("signedParam: valid\n", "signedParam: valid\n")
+	ReadInConfig()
+	viper.SetConfigFile(file)
+	if err := Reload(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := GetString("signedParam"); got != "valid" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "valid")
+	}
+
+	write("signedParam: tampered\n", "signedParam: valid\n")
+	if err := Reload(); !errors.Is(err, ErrSignature) {
+		t.Errorf("Expected ErrSignature: %v", err)
+	}
+
+	if got := GetString("signedParam"); got != "valid" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "valid")
+	}
+}
+
+func TestSignedConfigLoad(t *testing.T) {
+
+	defer func(loader func()) {
+		ConfigLoader = loader
+		Reset()
+	}(ConfigLoader)
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetVerifyKey(base64.StdEncoding.EncodeToString(pub)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer SetVerifyKey("")
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(file, []byte("signedParam: tampered\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte("signedParam: valid\n")))
+	if err := ioutil.WriteFile(file+".sig", []byte(sig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ConfigLoader = func() {
+		viper.SetConfigFile(file)
+		viper.ReadInConfig()
+	}
+	Reset()
+
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, ErrSignature) {
+			t.Errorf("Expected ErrSignature: %v", err)
+		}
+	}()
+	GetString("signedParam")
+}
+
+func TestVerifyWithoutKey(t *testing.T) {
+
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("name: signed\n")
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, data))
+
+	if err := VerifySignature(data, []byte(sig)); !errors.Is(err, ErrSignature) {
+		t.Errorf("Expected ErrSignature: %v", err)
+	}
+}
+
+func TestSignedConfigWrite(t *testing.T) {
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := "signedParam: valid\n"
+	file := useConfigFile(t, content)
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(content)))
+	if err := ioutil.WriteFile(file+".sig", []byte(sig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetVerifyKey(base64.StdEncoding.EncodeToString(pub)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer SetVerifyKey("")
+
+	Set("signedParam", "edited")
+	if err := Write(); !errors.Is(err, ErrSignedConfig) {
+		t.Errorf("Expected ErrSignedConfig: %v", err)
+	}
+
+	tx := Begin()
+	tx.Set("signedParam", "edited")
+	if err := tx.Commit(); !errors.Is(err, ErrSignedConfig) {
+		t.Errorf("Expected ErrSignedConfig: %v", err)
+	}
+
+	if err := Reload(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if b, _ := ioutil.ReadFile(file); string(b) != content {
+		t.Errorf("\ngot:  %q\nwant: %q\n", b, content)
+	}
+}
+
+func TestVerifyMinisignSignature(t *testing.T) {
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID := []byte("12345678")
+	pubKey := base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...))
+	if err := SetVerifyKey("untrusted comment: minisign public key\n" + pubKey + "\n"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer SetVerifyKey("")
+
+	data := []byte("name: signed\n")
+	sig := base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), ed25519.Sign(priv, data)...))
+	sigFile := "untrusted comment: signature\n" + sig + "\ntrusted comment: timestamp\nglobal\n"
+
+	if err := VerifySignature(data, []byte(sigFile)); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if err := VerifySignature([]byte("name: other\n"), []byte(sigFile)); !errors.Is(err, ErrSignature) {
+		t.Errorf("Expected ErrSignature: %v", err)
+	}
+}
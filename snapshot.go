@@ -0,0 +1,92 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+// State is a copy of the config taken with Snapshot
+type State struct {
+	file      map[string]interface{}
+	fragments []fragment
+	set       map[string]interface{}
+	overrides []*Overrides
+	execRefs  map[string]execRef
+}
+
+// Snapshot captures the config read from the file, the merged fragments and the values that were Set,
+// so they can be rolled back with Restore
+func Snapshot() *State {
+	loadConfig()
+	mu.RLock()
+	defer mu.RUnlock()
+	s := &State{
+		file:      deepCopy(layers.file).(map[string]interface{}),
+		fragments: append([]fragment(nil), layers.fragments...),
+		set:       deepCopy(layers.set).(map[string]interface{}),
+		overrides: append([]*Overrides(nil), overrideStack...),
+		execRefs:  make(map[string]execRef, len(execRefs)),
+	}
+	for key, r := range execRefs {
+		s.execRefs[key] = r
+	}
+	return s
+}
+
+// Restore replaces the config with the captured state. Values Set after the Snapshot are removed.
+// The environment, the defaults and the bound flags are kept.
+func Restore(s *State) error {
+	loadConfig()
+	mu.Lock()
+	defer resetCache()
+	defer mu.Unlock()
+	prev := overriddenKeys()
+	layers.file = deepCopy(s.file).(map[string]interface{})
+	layers.fragments = append([]fragment(nil), s.fragments...)
+	layers.set = deepCopy(s.set).(map[string]interface{})
+	overrideStack = append([]*Overrides(nil), s.overrides...)
+	execRefs = make(map[string]execRef, len(s.execRefs))
+	for key, r := range s.execRefs {
+		execRefs[key] = r
+	}
+	if err := rebuildConfig(); err != nil {
+		return err
+	}
+	for top := range prev {
+		syncOverride(top)
+	}
+	for top := range overriddenKeys() {
+		syncOverride(top)
+	}
+	return nil
+}
+
+// deepCopy copies the nested maps and slices of a config value
+func deepCopy(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[k] = deepCopy(val)
+		}
+		return m
+	case map[interface{}]interface{}:
+		m := make(map[interface{}]interface{}, len(v))
+		for k, val := range v {
+			m[k] = deepCopy(val)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, val := range v {
+			s[i] = deepCopy(val)
+		}
+		return s
+	case []map[string]interface{}:
+		s := make([]map[string]interface{}, len(v))
+		for i, val := range v {
+			s[i] = deepCopy(val).(map[string]interface{})
+		}
+		return s
+	}
+	return v
+}
@@ -0,0 +1,77 @@
+package cfg
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+
+	Set("snapshotSection", map[string]interface{}{"name": "Before"})
+	state := Snapshot()
+
+	Set("snapshotSection.name", "After")
+	Set("snapshotAdded", "Added")
+
+	if got := GetString("snapshotSection.name"); got != "After" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "After")
+	}
+
+	if err := Restore(state); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := GetString("snapshotSection.name"); got != "Before" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "Before")
+	}
+
+	if got := Get("snapshotAdded"); got != nil {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, nil)
+	}
+
+	if got := GetString("firstParam"); got != "First" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "First")
+	}
+}
+
+func TestRestoreKeepsLayers(t *testing.T) {
+
+	Reset()
+	defer Reset()
+	t.Setenv("RESTOREENV", "Env")
+	viper.AutomaticEnv()
+	SetDefault("restoreDefault", "Default")
+	flags := pflag.NewFlagSet("restore", pflag.ContinueOnError)
+	flags.String("restore-flag", "", "")
+	flags.Set("restore-flag", "Flag")
+	mu.Lock()
+	bindGlobalPFlag("restoreFlag", flags.Lookup("restore-flag"))
+	mu.Unlock()
+
+	state := Snapshot()
+	Set("restoreSet", "Set")
+
+	if err := Restore(state); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for key, want := range map[string]string{"restoreEnv": "Env", "restoreDefault": "Default", "restoreFlag": "Flag", "firstParam": "First"} {
+		if got := GetString(key); got != want {
+			t.Errorf("\ngot:  %v\nwant: %v\n", got, want)
+		}
+	}
+
+	if got := Get("restoreSet"); got != nil {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, nil)
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, key := range []string{"restoreenv", "restoredefault", "restoreflag", "restoreset"} {
+		if viper.InConfig(key) {
+			t.Errorf("Unexpected config value for %s", key)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// boundFlags holds the flags bound to keys of the global config, guarded by mu
+var boundFlags map[string]*pflag.Flag
+
+// bindGlobalPFlag binds the flag to the key of the global config. The caller must hold the write lock.
+func bindGlobalPFlag(key string, flag *pflag.Flag) error {
+	if err := viper.BindPFlag(key, flag); err != nil {
+		return err
+	}
+	if boundFlags == nil {
+		boundFlags = make(map[string]*pflag.Flag)
+	}
+	boundFlags[strings.ToLower(key)] = flag
+	return nil
+}
+
+// Candidate is a value a layer has for a key
+type Candidate struct {
+	Source string
+	Value  interface{}
+}
+
+// Resolution is how the value of a key was resolved: the value, the source it came from
+// and the values of the other layers that lost
+type Resolution struct {
+	Key    string
+	Value  interface{}
+	Source string
+	Losing []Candidate
+}
+
+// Resolutions returns how each key of the effective config was resolved, sorted by key.
+// Sources are flag, env, file and default in the order they win, or runtime for values that were Set.
+// Environment variables are found with the settings made with AutomaticEnv, SetEnvPrefix, SetEnvKeyReplacer and BindEnv.
+// Values of secret keys are redacted.
+func Resolutions() []Resolution {
+	loadConfig()
+	mu.RLock()
+	onDisk := fileSettings(viper.ConfigFileUsed())
+	keys := allKeys()
+	sort.Strings(keys)
+	resolutions := make([]Resolution, 0, len(keys))
+	for _, key := range keys {
+		r := Resolution{Key: key, Value: viper.Get(key)}
+		var candidates []Candidate
+		if flag, ok := boundFlags[key]; ok && flag.Changed {
+			candidates = append(candidates, Candidate{"flag", flag.Value.String()})
+		}
+		if value, ok := lookupEnv(key); ok {
+			candidates = append(candidates, Candidate{"env", value})
+		}
+		if value, ok := onDisk[key]; ok {
+			candidates = append(candidates, Candidate{"file", value})
+		}
+		if value, ok := defaultValues[key]; ok {
+			candidates = append(candidates, Candidate{"default", value})
+		}
+		r.Source = "runtime"
+		if len(candidates) > 0 && sameValue(candidates[0].Value, r.Value) {
+			r.Source, candidates = candidates[0].Source, candidates[1:]
+		} else if viper.InConfig(key) && (len(candidates) == 0 || candidates[0].Source == "file" || candidates[0].Source == "default") {
+			// merged into the config without being on disk
+			r.Source = "file"
+		}
+		r.Losing = candidates
+		resolutions = append(resolutions, r)
+	}
+	mu.RUnlock()
+	for i, r := range resolutions {
+		resolutions[i].Value = redact(r.Key, r.Value)
+		for j := range r.Losing {
+			r.Losing[j].Value = redact(r.Key, r.Losing[j].Value)
+		}
+	}
+	return resolutions
+}
+
+// fileSettings returns the leaf values of the config file by their lowercase dot keys,
+// as the file is on disk without merged fragments, migrations and values that were Set
+func fileSettings(file string) map[string]interface{} {
+	flat := make(map[string]interface{})
+	if file == "" {
+		return flat
+	}
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err == nil {
+		flattenSettings(v.AllSettings(), "", flat)
+	}
+	return flat
+}
+
+// sameValue reports whether the values are equal, comparing strings from flags and env by their formatting
+func sameValue(a interface{}, b interface{}) bool {
+	return jsonEqual(a, b) || fmt.Sprintf("%v", a) == fmt.Sprintf("%v", b)
+}
+
+// losingValues returns the values the resolved value won from, like " (file: 80, default: 8000)"
+func losingValues(r Resolution) string {
+	if len(r.Losing) == 0 {
+		return ""
+	}
+	losing := make([]string, len(r.Losing))
+	for i, c := range r.Losing {
+		losing[i] = c.Source + ": " + flatValue(c.Value)
+	}
+	return " (" + strings.Join(losing, ", ") + ")"
+}
+
+// DumpSources writes the effective config as a YAML-like tree annotated with the source of each key
+// and the values it won from. Values of secret keys are redacted.
+//
+//	server:
+//	  port: 8080  # flag (file: 80, default: 8000)
+func DumpSources(w io.Writer) error {
+	var prev []string
+	for _, r := range Resolutions() {
+		path := strings.Split(r.Key, ".")
+		common := 0
+		for common < len(prev) && common < len(path)-1 && prev[common] == path[common] {
+			common++
+		}
+		for i := common; i < len(path)-1; i++ {
+			if _, err := fmt.Fprintf(w, "%s%s:\n", strings.Repeat("  ", i), path[i]); err != nil {
+				return err
+			}
+		}
+		prev = path[:len(path)-1]
+		line := fmt.Sprintf("%s%s: %s  # %s%s", strings.Repeat("  ", len(path)-1), path[len(path)-1], flatValue(r.Value), r.Source, losingValues(r))
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package cfg
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+func TestDumpSources(t *testing.T) {
+
+	Reset()
+	defer Reset()
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(file, []byte("srcSection:\n  port: 80\n  name: fromFile\n  apiKey: hidden\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ReadInConfig()
+	viper.SetConfigFile(file)
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	SetDefault("srcSection.port", 8000)
+	SetDefault("srcSection.host", "localhost")
+	Set("srcSection.mode", "fast")
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.Int("port", 0, "")
+	if err := flags.Parse([]string{"--port", "8080"}); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	err = bindGlobalPFlag("srcSection.port", flags.Lookup("port"))
+	mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := DumpSources(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := "srcsection:\n" +
+		"  apikey: [redacted]  # file\n" +
+		"  host: localhost  # default\n" +
+		"  mode: fast  # runtime\n" +
+		"  name: fromFile  # file\n" +
+		"  port: 8080  # flag (file: 80, default: 8000)\n"
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("\ngot:  %q\nwant: %q\n", got, want)
+	}
+
+	output, err := executeCommand(NewConfigCommand(), "show", "--sources")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(output, want) {
+		t.Errorf("Unexpected output:\n%s", output)
+	}
+}
+
+func TestResolutionsEnvNames(t *testing.T) {
+
+	useConfigFile(t, "envSection:\n  host: fromFile\n  port: 80\n")
+	t.Setenv("APP_ENVSECTION_HOST", "fromEnv")
+	t.Setenv("CUSTOM_PORT", "8080")
+	SetEnvPrefix("app")
+	SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	AutomaticEnv()
+	if err := BindEnv("envSection.port", "CUSTOM_PORT"); err != nil {
+		t.Fatal(err)
+	}
+
+	sources := make(map[string]string)
+	for _, r := range Resolutions() {
+		sources[r.Key] = r.Source
+	}
+
+	for key, want := range map[string]string{"envsection.host": "env", "envsection.port": "env"} {
+		if got := sources[key]; got != want {
+			t.Errorf("%s\ngot:  %v\nwant: %v\n", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"sync"
+	"time"
+)
+
+// LoadStats holds counters about loading the config, to be exported by metrics collectors
+type LoadStats struct {
+	// LoadDuration is how long the last load or reload took
+	LoadDuration time.Duration
+	// Reloads counts the calls to Reload
+	Reloads int
+	// ReloadFailures counts the calls to Reload that failed
+	ReloadFailures int
+	// LastSuccess is when the config was last loaded or reloaded without error
+	LastSuccess time.Time
+	// Fetches counts the fetches of the sources of PollSource, including retries and signatures
+	Fetches int
+	// FetchFailures counts the fetches that failed
+	FetchFailures int
+}
+
+var stats struct {
+	sync.Mutex
+	LoadStats
+}
+
+// Stats returns the load counters
+func Stats() LoadStats {
+	stats.Lock()
+	defer stats.Unlock()
+	return stats.LoadStats
+}
+
+// recordLoad updates the counters after a load that started at start
+func recordLoad(start time.Time, reload bool, err error) {
+	stats.Lock()
+	defer stats.Unlock()
+	stats.LoadDuration = time.Since(start)
+	if reload {
+		stats.Reloads++
+	}
+	if err != nil {
+		stats.ReloadFailures++
+		return
+	}
+	stats.LastSuccess = time.Now()
+}
+
+// recordFetch updates the fetch counters after a fetch of a source
+func recordFetch(err error) {
+	stats.Lock()
+	defer stats.Unlock()
+	stats.Fetches++
+	if err != nil {
+		stats.FetchFailures++
+	}
+}
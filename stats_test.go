@@ -0,0 +1,93 @@
+package cfg
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestStats(t *testing.T) {
+
+	Reset()
+	defer Reset()
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	viper.SetConfigFile(file)
+
+	before := Stats()
+	if err := Reload(); err == nil {
+		t.Fatalf("Expected an error reloading a missing file")
+	}
+	if err := ioutil.WriteFile(file, []byte("statsParam: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Reload(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got := Stats()
+	if got.Reloads != before.Reloads+2 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got.Reloads, before.Reloads+2)
+	}
+	if got.ReloadFailures != before.ReloadFailures+1 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got.ReloadFailures, before.ReloadFailures+1)
+	}
+	if !got.LastSuccess.After(before.LastSuccess) {
+		t.Errorf("Expected LastSuccess to be updated")
+	}
+}
+
+func TestFetchStats(t *testing.T) {
+
+	t.Cleanup(Reset)
+	defer func(w io.Writer) { WarningOutput = w }(WarningOutput)
+	WarningOutput = new(bytes.Buffer)
+	var spans []string
+	SetTracer(func(name string) func(error) {
+		spans = append(spans, name)
+		return func(error) {}
+	})
+	defer SetTracer(nil)
+
+	before := Stats()
+	failing := func() ([]byte, error) { return nil, errors.New("unavailable") }
+	stop, err := PollSource(failing, "yaml", time.Hour)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	stop()
+	fetch := func() ([]byte, error) { return []byte("fetchStats: true\n"), nil }
+	if stop, err = PollSource(fetch, "yaml", time.Hour); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	stop()
+
+	got := Stats()
+	if got.Fetches != before.Fetches+2 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got.Fetches, before.Fetches+2)
+	}
+	if got.FetchFailures != before.FetchFailures+1 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got.FetchFailures, before.FetchFailures+1)
+	}
+	fetches := 0
+	for _, span := range spans {
+		if span == "cfg.fetch" {
+			fetches++
+		}
+	}
+	if fetches != 2 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", fetches, 2)
+	}
+}
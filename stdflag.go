@@ -0,0 +1,39 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"flag"
+
+	"github.com/spf13/pflag"
+)
+
+// CreateStdFlags generates flags on a standard library FlagSet based on a Struct
+func CreateStdFlags(fs *flag.FlagSet, rawVal interface{}, options ...func(*BindOptions)) {
+	flags := pflag.NewFlagSet(fs.Name(), pflag.ContinueOnError)
+	CreateFlags(flags, rawVal, options...)
+	flags.VisitAll(func(f *pflag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+}
+
+// ResolveStdFlags unmarshals the config into a Struct overriding with any flags that are set.
+// Call it after parsing a FlagSet created with CreateStdFlags.
+func ResolveStdFlags(fs *flag.FlagSet, rawVal interface{}, options ...func(*BindOptions)) error {
+	flags := pflag.NewFlagSet(fs.Name(), pflag.ContinueOnError)
+	flags.AddGoFlagSet(fs)
+	fs.Visit(func(f *flag.Flag) {
+		flags.Lookup(f.Name).Changed = true
+	})
+	if err := Resolve(flags, rawVal, options...); err != nil {
+		return err
+	}
+	flags.VisitAll(func(f *pflag.Flag) {
+		if gf := fs.Lookup(f.Name); gf != nil {
+			gf.DefValue = f.DefValue
+		}
+	})
+	return nil
+}
@@ -0,0 +1,36 @@
+package cfg
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestResolveStdFlagSet(t *testing.T) {
+
+	var config child2Struct
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	CreateStdFlags(fs, &config)
+
+	if err := fs.Parse([]string{"-fifth-param", "102"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if err := ResolveStdFlags(fs, &config, Key("nested")); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	want := child2Struct{
+		FourthParam: true,
+		FifthParam:  102,
+		SixthParam:  "Sixth",
+	}
+
+	if config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+
+	if def := fs.Lookup("sixth-param").DefValue; def != "Sixth" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", def, "Sixth")
+	}
+}
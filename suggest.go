@@ -0,0 +1,97 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// configKeys returns the keys of the config and the bound Structs, sorted. The caller must hold the read lock.
+func configKeys() []string {
+	keys := allKeys()
+	schemaKeys.Lock()
+	for key := range schemaKeys.keys {
+		if !viper.IsSet(key) {
+			keys = append(keys, key)
+		}
+	}
+	schemaKeys.Unlock()
+	sort.Strings(keys)
+	return keys
+}
+
+// suggestPath returns the nearest config or Struct key for a path that is neither a key nor a section,
+// or an empty string. Paths with list selectors get no suggestion. The caller must hold the read lock.
+func suggestPath(path string) string {
+	if strings.ContainsAny(path, "[]") {
+		return ""
+	}
+	path = strings.ToLower(path)
+	keys := configKeys()
+	for _, key := range keys {
+		if key == path || strings.HasPrefix(key, path+".") || strings.HasPrefix(path, key+".") {
+			return ""
+		}
+	}
+	return suggestKey(path, keys)
+}
+
+// notSetError returns the error for a path that is not set, suggesting the nearest key.
+// The caller must hold the read lock.
+func notSetError(path string) error {
+	if s := suggestPath(path); s != "" {
+		return fmt.Errorf("config key %q is not set, did you mean %q?", path, s)
+	}
+	return fmt.Errorf("config key %q is not set", path)
+}
+
+// suggestKey returns the candidate nearest to the key by Levenshtein distance, or an empty string
+// when none is within two edits or a third of the length of the key
+func suggestKey(key string, candidates []string) string {
+	key = strings.ToLower(key)
+	maxDist := len(key) / 3
+	if maxDist < 2 {
+		maxDist = 2
+	}
+	best, bestDist := "", maxDist+1
+	for _, c := range candidates {
+		if d := levenshtein(key, strings.ToLower(c)); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// levenshtein returns the number of single character edits to change a into b
+func levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if d := prev[j] + 1; d < cur[j] {
+				cur[j] = d
+			}
+			if d := cur[j-1] + 1; d < cur[j] {
+				cur[j] = d
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,29 @@
+package cfg
+
+import "testing"
+
+func TestSuggestKey(t *testing.T) {
+
+	candidates := []string{"firstParam", "secondParam", "section.secondParam"}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"secondParm", "secondParam"},
+		{"SECONDPARAM", "secondParam"},
+		{"section.secondparm", "section.secondParam"},
+		{"other", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if got := suggestKey(test.key, candidates); got != test.want {
+			t.Errorf("%s\ngot:  %v\nwant: %v\n", test.key, got, test.want)
+		}
+	}
+
+	if got := levenshtein("kitten", "sitting"); got != 3 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, 3)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// TemplateData holds the values available to config templates, like {{.App}} or {{.Hostname}}
+type TemplateData struct {
+	// App is the name of the executable, without .exe
+	App string
+	// Profile is set with the --profile flag of config init
+	Profile  string
+	Hostname string
+	User     string
+	Home     string
+	OS       string
+	Arch     string
+}
+
+var templates struct {
+	sync.Mutex
+	byName map[string]string
+}
+
+// RegisterTemplate registers a config template that config init --template name scaffolds.
+// The content is a text/template for the format of its file extension, like an embedded file:
+//
+//	//go:embed kubernetes.yaml
+//	var kubernetes string
+//
+//	cfg.RegisterTemplate("kubernetes", kubernetes)
+func RegisterTemplate(name string, content string) {
+	templates.Lock()
+	defer templates.Unlock()
+	if templates.byName == nil {
+		templates.byName = make(map[string]string)
+	}
+	templates.byName[name] = content
+}
+
+// templateNames returns the names of the registered templates in order
+func templateNames() []string {
+	templates.Lock()
+	defer templates.Unlock()
+	var names []string
+	for name := range templates.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RenderTemplate renders the registered template with the data
+func RenderTemplate(name string, data TemplateData) ([]byte, error) {
+	templates.Lock()
+	content, ok := templates.byName[name]
+	templates.Unlock()
+	if !ok {
+		return nil, &ErrItemNotFound{Collection: "template", ID: name, Available: templateNames()}
+	}
+	t, err := template.New(name).Option("missingkey=error").Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// detectTemplateData returns the template data of the running process
+func detectTemplateData(profile string) TemplateData {
+	data := TemplateData{Profile: profile, OS: runtime.GOOS, Arch: runtime.GOARCH}
+	if exec, err := os.Executable(); err == nil {
+		data.App = strings.TrimSuffix(filepath.Base(exec), ".exe")
+	}
+	data.Hostname, _ = os.Hostname()
+	if u, err := user.Current(); err == nil {
+		data.User = u.Username
+	}
+	data.Home, _ = homedir.Dir()
+	return data
+}
+
+// newInitCommand creates the config init command scaffolding a config file from a template
+func newInitCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "init",
+		Short: "Create a config file from a template",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, _ := cmd.Flags().GetString("template")
+			if name == "" {
+				names := templateNames()
+				if len(names) != 1 {
+					return errors.New("choose a template with --template: " + strings.Join(names, ", "))
+				}
+				name = names[0]
+			}
+			profile, _ := cmd.Flags().GetString("profile")
+			data := detectTemplateData(profile)
+			b, err := RenderTemplate(name, data)
+			if err != nil {
+				return err
+			}
+			file, _ := cmd.Flags().GetString("file")
+			if file == "" {
+				file = configFile()
+			}
+			var question string
+			if file == "" {
+				file = filepath.Join(data.Home, "."+data.App+".yaml")
+				question = fmt.Sprintf("No config file is in use, create %s?", file)
+			}
+			if err := checkTemplate(b, file); err != nil {
+				return fmt.Errorf("template %s: %w", name, err)
+			}
+			if _, err := os.Stat(file); err == nil {
+				question = fmt.Sprintf("Overwrite %s?", file)
+			}
+			if question != "" {
+				if err := Confirm(cmd, question); err != nil {
+					return err
+				}
+			}
+			if err := os.WriteFile(file, b, 0600); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Created %s from template %s\n", file, name)
+			return nil
+		},
+	}
+	c.Flags().String("template", "", "Name of the template")
+	c.Flags().String("profile", "", "Profile used by the template")
+	c.Flags().String("file", "", "Config file to create instead of the config file in use")
+	AddConfirmFlags(c)
+	_ = c.RegisterFlagCompletionFunc("template", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return templateNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+	return c
+}
+
+// checkTemplate checks that the rendered template parses in the format of the file
+func checkTemplate(b []byte, file string) error {
+	v := viper.New()
+	v.SetConfigType(strings.TrimPrefix(filepath.Ext(file), "."))
+	return v.ReadConfig(bytes.NewReader(b))
+}
@@ -0,0 +1,50 @@
+package cfg
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigInitTemplate(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+
+	RegisterTemplate("kubernetes", "profile: {{.Profile}}\nos: {{.OS}}\nlisten: :8080\n")
+	RegisterTemplate("broken", "profile: [{{.Profile}}\n")
+
+	if _, err := executeCommand(NewConfigCommand(), "init", "--template", "kubernetes", "--profile", "prod", "--file", file); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "profile: prod\nos: " + detectTemplateData("").OS + "\nlisten: :8080\n"
+	if string(b) != want {
+		t.Errorf("\ngot:  %q\nwant: %q\n", b, want)
+	}
+
+	if _, err := executeCommand(NewConfigCommand(), "init", "--template", "kubernetes", "--file", file, "--yes"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	_, err = executeCommand(NewConfigCommand(), "init", "--template", "missing", "--file", file)
+	var notFound *ErrItemNotFound
+	if !errors.As(err, &notFound) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if _, err := executeCommand(NewConfigCommand(), "init", "--template", "broken", "--file", filepath.Join(dir, "broken.yaml")); err == nil {
+		t.Errorf("Expected an error for a template that doesn't parse")
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import "sync"
+
+// TraceFunc starts a span with the name and returns the function that ends it with the result of the step
+type TraceFunc func(name string) (end func(err error))
+
+var tracer struct {
+	sync.RWMutex
+	f TraceFunc
+}
+
+// SetTracer traces the steps of loading the config, like reading the config file, reloading,
+// fetching sources and unmarshalling bound Structs. An OpenTelemetry tracer can be adapted like:
+//
+//	cfg.SetTracer(func(name string) func(error) {
+//		_, span := otel.Tracer("cfg").Start(ctx, name)
+//		return func(err error) {
+//			if err != nil {
+//				span.RecordError(err)
+//			}
+//			span.End()
+//		}
+//	})
+//
+// A nil TraceFunc disables tracing.
+func SetTracer(f TraceFunc) {
+	tracer.Lock()
+	defer tracer.Unlock()
+	tracer.f = f
+}
+
+// startSpan starts a span when tracing is enabled
+func startSpan(name string) func(err error) {
+	tracer.RLock()
+	f := tracer.f
+	tracer.RUnlock()
+	if f == nil {
+		return func(error) {}
+	}
+	return f(name)
+}
@@ -0,0 +1,24 @@
+package cfg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetTracer(t *testing.T) {
+
+	var spans []string
+	SetTracer(func(name string) func(error) {
+		spans = append(spans, name)
+		return func(error) {}
+	})
+	defer SetTracer(nil)
+
+	Reset()
+	defer Reset()
+	GetString("firstParam")
+
+	if want := []string{"cfg.load"}; !reflect.DeepEqual(spans, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", spans, want)
+	}
+}
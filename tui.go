@@ -0,0 +1,268 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// newTUICommand creates the config tui command browsing and editing the effective config in the terminal
+func newTUICommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "tui",
+		Short: "Browse and edit the effective config in the terminal",
+		Long: "Browse the effective config as a tree with the source of each key, the values it won from and secrets redacted. " +
+			"Move with the arrow keys or j and k, open and close sections with enter or the right and left keys, " +
+			"edit a value with e and quit with q. Edited values are written to the config file. " +
+			"Keys set by flags, environment variables or at runtime can't be edited, as they win over the config file.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in, ok := cmd.InOrStdin().(*os.File)
+			if !ok || !term.IsTerminal(int(in.Fd())) {
+				return errors.New("config tui needs a terminal, use config show instead")
+			}
+			p := tea.NewProgram(newBrowser(Resolutions, saveValue),
+				tea.WithInput(in), tea.WithOutput(cmd.OutOrStdout()), tea.WithAltScreen())
+			_, err := p.Run()
+			return err
+		},
+	}
+}
+
+// saveValue writes the edited value of the key to the config file, parsed like config set does
+func saveValue(key string, value string) error {
+	tx := Begin()
+	tx.Set(key, parseValue(key, value))
+	return tx.Commit()
+}
+
+// browser is the bubbletea model of the config tree shown by config tui
+type browser struct {
+	load        func() []Resolution
+	save        func(key string, value string) error
+	resolutions map[string]Resolution
+	keys        []string
+	open        map[string]bool
+	rows        []browserRow
+	cursor      int
+	height      int
+	// editing is the key whose value is being typed into input
+	editing string
+	input   []rune
+	status  string
+}
+
+// browserRow is a section or a key shown in the tree
+type browserRow struct {
+	key     string
+	depth   int
+	section bool
+}
+
+func newBrowser(load func() []Resolution, save func(key string, value string) error) *browser {
+	b := &browser{load: load, save: save, open: make(map[string]bool)}
+	b.refresh()
+	return b
+}
+
+// refresh reloads the keys of the tree, keeping the open sections and the selected key
+func (b *browser) refresh() {
+	selected := b.current().key
+	resolutions := b.load()
+	b.resolutions = make(map[string]Resolution, len(resolutions))
+	b.keys = b.keys[:0]
+	for _, r := range resolutions {
+		b.resolutions[r.Key] = r
+		b.keys = append(b.keys, r.Key)
+	}
+	sort.Strings(b.keys)
+	b.buildRows()
+	b.selectKey(selected)
+}
+
+// buildRows lists the sections and keys whose parent sections are open
+func (b *browser) buildRows() {
+	b.rows = b.rows[:0]
+	seen := make(map[string]bool)
+	for _, key := range b.keys {
+		parts := strings.Split(key, ".")
+		for depth := range parts {
+			path := strings.Join(parts[:depth+1], ".")
+			if !seen[path] {
+				seen[path] = true
+				b.rows = append(b.rows, browserRow{key: path, depth: depth, section: depth < len(parts)-1})
+			}
+			if depth < len(parts)-1 && !b.open[path] {
+				break
+			}
+		}
+	}
+	if b.cursor >= len(b.rows) {
+		b.cursor = len(b.rows) - 1
+	}
+	if b.cursor < 0 {
+		b.cursor = 0
+	}
+}
+
+// selectKey moves the cursor to the row of the key when it is shown
+func (b *browser) selectKey(key string) {
+	for i, row := range b.rows {
+		if row.key == key {
+			b.cursor = i
+		}
+	}
+}
+
+// current returns the selected row
+func (b *browser) current() browserRow {
+	if b.cursor < len(b.rows) {
+		return b.rows[b.cursor]
+	}
+	return browserRow{}
+}
+
+func (b *browser) Init() tea.Cmd {
+	return nil
+}
+
+func (b *browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.height = msg.Height
+	case tea.KeyMsg:
+		if b.editing != "" {
+			b.edit(msg)
+			return b, nil
+		}
+		return b, b.handle(msg.String())
+	}
+	return b, nil
+}
+
+// handle applies the key press while browsing
+func (b *browser) handle(key string) tea.Cmd {
+	row := b.current()
+	switch key {
+	case "up", "k":
+		if b.cursor > 0 {
+			b.cursor--
+		}
+	case "down", "j":
+		if b.cursor < len(b.rows)-1 {
+			b.cursor++
+		}
+	case "right", "l", "enter":
+		if row.section {
+			// enter toggles the section, right only opens it
+			b.open[row.key] = key != "enter" || !b.open[row.key]
+			b.buildRows()
+		}
+	case "left", "h":
+		if !row.section || !b.open[row.key] {
+			// close the parent section instead
+			if i := strings.LastIndex(row.key, "."); i >= 0 {
+				row.key = row.key[:i]
+			}
+		}
+		delete(b.open, row.key)
+		b.buildRows()
+		b.selectKey(row.key)
+	case "e":
+		if row.key == "" || row.section {
+			break
+		}
+		if r := b.resolutions[row.key]; r.Source != "file" && r.Source != "default" {
+			// the edit would be written to the config file without changing the value
+			b.status = fmt.Sprintf("Can't edit %s, its %s value wins over the config file", row.key, r.Source)
+			break
+		}
+		b.editing, b.input, b.status = row.key, nil, ""
+	case "q", "ctrl+c":
+		return tea.Quit
+	}
+	return nil
+}
+
+// edit applies the key press while a value is typed, saving it on enter
+func (b *browser) edit(msg tea.KeyMsg) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		key, value := b.editing, strings.TrimSpace(string(b.input))
+		b.editing, b.input = "", nil
+		if value == "" {
+			b.status = "Unchanged " + key
+			return
+		}
+		if err := b.save(key, value); err != nil {
+			b.status = err.Error()
+			return
+		}
+		b.status = "Saved " + key
+		b.refresh()
+	case tea.KeyEsc, tea.KeyCtrlC:
+		b.status = "Unchanged " + b.editing
+		b.editing, b.input = "", nil
+	case tea.KeyBackspace:
+		if len(b.input) > 0 {
+			b.input = b.input[:len(b.input)-1]
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		b.input = append(b.input, msg.Runes...)
+	}
+}
+
+// View draws the rows that fit the height around the cursor, with a help line and the status or the edited value
+func (b *browser) View() string {
+	var s strings.Builder
+	s.WriteString("config: arrows or j/k move, enter opens, e edits, q quits\n")
+	lines := b.height - 2
+	if b.height == 0 {
+		lines = 22
+	}
+	if lines < 1 {
+		lines = 1
+	}
+	start := 0
+	if b.cursor >= lines {
+		start = b.cursor - lines + 1
+	}
+	for i := start; i < len(b.rows) && i < start+lines; i++ {
+		row := b.rows[i]
+		prefix := "  "
+		if i == b.cursor {
+			prefix = "> "
+		}
+		name := row.key[strings.LastIndex(row.key, ".")+1:]
+		indent := strings.Repeat("  ", row.depth)
+		switch {
+		case row.section && b.open[row.key]:
+			fmt.Fprintf(&s, "%s%s- %s\n", prefix, indent, name)
+		case row.section:
+			fmt.Fprintf(&s, "%s%s+ %s\n", prefix, indent, name)
+		default:
+			r := b.resolutions[row.key]
+			fmt.Fprintf(&s, "%s%s  %s: %s  # %s%s\n", prefix, indent, name, flatValue(r.Value), r.Source, losingValues(r))
+		}
+	}
+	if b.editing != "" {
+		current, input := b.resolutions[b.editing].Value, string(b.input)
+		if current == Redacted {
+			input = strings.Repeat("*", len(b.input))
+		}
+		fmt.Fprintf(&s, "%s [%s]: %s", b.editing, flatValue(current), input)
+	} else {
+		s.WriteString(b.status)
+	}
+	return s.String()
+}
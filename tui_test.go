@@ -0,0 +1,104 @@
+package cfg
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyMsg returns the key press of the bubbletea key name, or of the typed runes
+func keyMsg(key string) tea.KeyMsg {
+	switch key {
+	case "up":
+		return tea.KeyMsg{Type: tea.KeyUp}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "left":
+		return tea.KeyMsg{Type: tea.KeyLeft}
+	case "right":
+		return tea.KeyMsg{Type: tea.KeyRight}
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "backspace":
+		return tea.KeyMsg{Type: tea.KeyBackspace}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+func TestBrowser(t *testing.T) {
+
+	resolutions := []Resolution{
+		{Key: "server.port", Value: 8080, Source: "env", Losing: []Candidate{{"file", 80}}},
+		{Key: "server.tls.cert", Value: "cert.pem", Source: "file"},
+		{Key: "name", Value: "app", Source: "default"},
+	}
+	var saved string
+	b := newBrowser(func() []Resolution { return resolutions }, func(key string, value string) error {
+		saved = key + "=" + value
+		resolutions[1].Value = value
+		return nil
+	})
+
+	rows := func() string {
+		var keys []string
+		for _, row := range b.rows {
+			keys = append(keys, row.key)
+		}
+		return strings.Join(keys, ",")
+	}
+	press := func(keys ...string) {
+		for _, key := range keys {
+			b.Update(keyMsg(key))
+		}
+	}
+
+	if got, want := rows(), "name,server"; got != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, want)
+	}
+
+	press("down", "enter", "down", "down", "right")
+
+	if got, want := rows(), "name,server,server.port,server.tls,server.tls.cert"; got != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, want)
+	}
+
+	press("up", "e")
+
+	if got, want := b.status, "Can't edit server.port, its env value wins over the config file"; got != want || b.editing != "" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, want)
+	}
+
+	if view := b.View(); !strings.Contains(view, ">     port: 8080  # env (file: 80)\n") {
+		t.Errorf("Unexpected output:\n%q", view)
+	}
+
+	press("down", "down", "e", "new", "x", "backspace", ".pem", "enter")
+
+	if got, want := saved, "server.tls.cert=new.pem"; got != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, want)
+	}
+
+	view := b.View()
+	if !strings.Contains(view, ">       cert: new.pem  # file\n") || !strings.Contains(view, "  - server\n") || !strings.HasSuffix(view, "Saved server.tls.cert") {
+		t.Errorf("Unexpected output:\n%q", view)
+	}
+
+	press("e", "other", "esc")
+	if got, want := saved, "server.tls.cert=new.pem"; got != want || b.editing != "" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, want)
+	}
+
+	press("left")
+	if got, want := rows(), "name,server,server.port,server.tls"; got != want || b.current().key != "server.tls" {
+		t.Errorf("\ngot:  %v at %s\nwant: %v at server.tls\n", got, b.current().key, want)
+	}
+
+	if _, cmd := b.Update(keyMsg("q")); cmd == nil {
+		t.Errorf("Expected q to quit")
+	} else if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Errorf("Expected q to quit")
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ErrTxDone is returned when a committed or rolled back transaction is used
+var ErrTxDone = errors.New("transaction already committed or rolled back")
+
+// Tx stages config edits that are validated and written together with Commit
+type Tx struct {
+	ops  []txOp
+	done bool
+}
+
+type txOp struct {
+	path  string
+	value interface{}
+	unset bool
+}
+
+// Begin starts a transaction
+func Begin() *Tx {
+	return &Tx{}
+}
+
+// Set stages setting the value at the path, which can address list items like SetPath
+func (tx *Tx) Set(path string, value interface{}) {
+	tx.ops = append(tx.ops, txOp{path: path, value: value})
+}
+
+// Unset stages removing the path like UnsetKey
+func (tx *Tx) Unset(path string) {
+	tx.ops = append(tx.ops, txOp{path: path, unset: true})
+}
+
+// Rollback discards the staged edits
+func (tx *Tx) Rollback() {
+	tx.ops = nil
+	tx.done = true
+}
+
+// Commit applies the staged edits to the settings of the config file, validates the result against the bound Structs
+// and writes the config file. Values from the environment, flags and defaults and values that were Set are not written.
+// The config file is locked while it is edited and read again when another process changed it.
+// Nothing is applied when an edit, the validation or writing fails.
+// The file is replaced atomically, so it is never partly written. Signed config files are not written, see SetVerifyKey.
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	loadConfig()
+	mu.RLock()
+	file := viper.ConfigFileUsed()
+	mu.RUnlock()
+	unlock, err := lockConfig(file)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	mu.Lock()
+	defer resetCache()
+	defer mu.Unlock()
+	if err := checkUnsigned(file); err != nil {
+		return err
+	}
+	var settings interface{} = deepCopy(layers.file)
+	if configChanged(file) {
+		// keep the edits another process wrote since the config was read
+		if settings, err = readConfigFile(file); err != nil {
+			return err
+		}
+	}
+	for _, op := range tx.ops {
+		steps, err := parsePath(op.path)
+		if err != nil {
+			return err
+		}
+		if !op.unset {
+			if settings, err = setStep(settings, steps, op.value); err != nil {
+				return fmt.Errorf("set %q: %w", op.path, err)
+			}
+			continue
+		}
+		var ok bool
+		if settings, ok = deleteStep(settings, steps); !ok {
+			return notSetError(op.path)
+		}
+	}
+	effective := mergeFragments(deepCopy(settings).(map[string]interface{}))
+	effective = overlay(effective, deepCopy(layers.set).(map[string]interface{}))
+	if err := validateSettings(effective, tx.paths()); err != nil {
+		return err
+	}
+	if err := writeAtomic(file, settings.(map[string]interface{})); err != nil {
+		return err
+	}
+	stampConfig()
+	layers.file = settings.(map[string]interface{})
+	return rebuildConfig()
+}
+
+// writeAtomic writes the settings to a temporary file that replaces the file, keeping the mode of the file.
+// The caller must hold the lock.
+func writeAtomic(file string, settings map[string]interface{}) error {
+	if file == "" {
+		return ErrConfigNotFound
+	}
+	ext := filepath.Ext(file)
+	tmp := strings.TrimSuffix(file, ext) + ".tmp" + ext
+	v := viper.New()
+	v.MergeConfigMap(deepCopy(settings).(map[string]interface{}))
+	if err := v.WriteConfigAs(tmp); err != nil {
+		return err
+	}
+	if info, err := os.Stat(file); err == nil {
+		// viper creates the file readable by everyone, which would expose the secrets of a private file
+		err = os.Chmod(tmp, info.Mode().Perm())
+		if err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	fmt.Println("Writing config:", file)
+	return nil
+}
+
+// paths returns the lowercase dot paths of the staged edits up to the first list selector
+func (tx *Tx) paths() []string {
+	paths := make([]string, len(tx.ops))
+	for i, op := range tx.ops {
+		paths[i] = strings.ToLower(strings.SplitN(op.path, "[", 2)[0])
+	}
+	return paths
+}
+
+// affects reports whether an edit of the path changes a field of the binding
+func (b schemaBinding) affects(path string) bool {
+	for _, f := range structFields(b.rt) {
+		key := strings.ToLower(b.keyName(f.name))
+		if b.key != "" {
+			key = b.key + "." + key
+		}
+		if path == key || strings.HasPrefix(path, key+".") || strings.HasPrefix(key, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// validateSettings decodes the settings of the bound Structs affected by the edited paths
+// and checks the choices and addr tags
+func validateSettings(settings map[string]interface{}, paths []string) error {
+	schemaKeys.Lock()
+	bindings := schemaKeys.bindings
+	schemaKeys.Unlock()
+	for _, b := range bindings {
+		affected := false
+		for _, path := range paths {
+			affected = affected || b.affects(path)
+		}
+		if !affected {
+			continue
+		}
+		if err := validateBinding(settings, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateBinding decodes the settings at the key of the binding into its Struct
+// and checks the choices and addr tags
+func validateBinding(settings map[string]interface{}, b schemaBinding) error {
+	section := settings
+	if b.key != "" {
+		steps, err := parsePath(b.key)
+		if err != nil {
+			return nil
+		}
+		var v interface{} = settings
+		for _, step := range steps {
+			if v, err = step.apply(reflect.ValueOf(v)); err != nil || v == nil {
+				break
+			}
+		}
+		m, ok := toStringMap(v)
+		if !ok {
+			return nil
+		}
+		section = m
+	}
+	rv := reflect.New(b.rt)
+	if err := decodeNamed(section, rv.Interface(), b.keyName); err != nil {
+		return &ErrDecode{Key: b.key, Type: b.rt.String(), Value: section, Err: err}
+	}
+	if err := validateChoices(rv.Elem(), b); err != nil {
+		return err
+	}
+	return validateBindingAddrs(rv.Elem(), b)
+}
+
+// validateChoices checks that fields with a choices tag have one of the choices or are empty
+func validateChoices(rv reflect.Value, b schemaBinding) error {
+	for _, f := range structFields(b.rt) {
+		if len(f.choices) == 0 || f.kind != reflect.String {
+			continue
+		}
+		value := rv.Field(f.index).String()
+		if value == "" {
+			continue
+		}
+		valid := false
+		for _, choice := range f.choices {
+			valid = valid || choice == value
+		}
+		if !valid {
+			key := strings.ToLower(b.keyName(f.name))
+			if b.key != "" {
+				key = b.key + "." + key
+			}
+			return &ErrValidation{Field: f.name, Key: key, Rule: fmt.Sprintf("%q is not one of %s", value, strings.Join(f.choices, ", "))}
+		}
+	}
+	return nil
+}
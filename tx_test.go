@@ -0,0 +1,149 @@
+package cfg
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type txStruct struct {
+	Port int
+	Mode string `choices:"fast,safe"`
+}
+
+func TestTx(t *testing.T) {
+
+	Reset()
+	defer Reset()
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(file, []byte("txSection:\n  port: 80\n  mode: fast\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ReadInConfig()
+	viper.SetConfigFile(file)
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	var config txStruct
+	BindFlags(&cobra.Command{Use: "tx"}, &config, Key("txSection"))
+
+	tx := Begin()
+	tx.Set("txSection.port", 8080)
+	tx.Set("txSection.mode", "slow")
+	err = tx.Commit()
+
+	var validationErr *ErrValidation
+	if !errors.As(err, &validationErr) || validationErr.Key != "txsection.mode" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if got := GetInt("txSection.port"); got != 80 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, 80)
+	}
+
+	tx = Begin()
+	tx.Set("txSection.port", "http")
+	var decodeErr *ErrDecode
+	if err := tx.Commit(); !errors.As(err, &decodeErr) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	tx = Begin()
+	tx.Set("txSection.port", 8080)
+	tx.Rollback()
+	if err := tx.Commit(); err != ErrTxDone {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	tx = Begin()
+	tx.Set("txSection.port", 8080)
+	tx.Unset("txSection.mode")
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "port: 8080") || strings.Contains(string(b), "mode") {
+		t.Errorf("Unexpected config file:\n%s", b)
+	}
+	if _, err := os.Stat(strings.TrimSuffix(file, ".yaml") + ".tmp.yaml"); !os.IsNotExist(err) {
+		t.Errorf("Expected the temporary file to be removed")
+	}
+}
+
+func TestTxWritesConfigFileOnly(t *testing.T) {
+
+	file := useConfigFile(t, "txFileSection:\n  port: 80\n")
+	t.Setenv("TXENV", "Env")
+	viper.AutomaticEnv()
+	SetDefault("txEnv", "")
+	SetDefault("txDefault", "Default")
+	Set("txSet", "Set")
+
+	if _, err := executeCommand(NewConfigCommand(), "set", "txFileSection.port", "8080"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "txfilesection:\n  port: 8080\n"; string(b) != want {
+		t.Errorf("\ngot:  %q\nwant: %q\n", b, want)
+	}
+
+	for key, want := range map[string]string{"txEnv": "Env", "txDefault": "Default", "txSet": "Set", "txFileSection.port": "8080"} {
+		if got := GetString(key); got != want {
+			t.Errorf("\ngot:  %v\nwant: %v\n", got, want)
+		}
+	}
+}
+
+func TestWriteKeepsFileMode(t *testing.T) {
+
+	file := useConfigFile(t, "modeSection:\n  token: secret\n")
+	if err := os.Chmod(file, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := Begin()
+	tx.Set("modeSection.port", 8080)
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	shared := filepath.Join(filepath.Dir(file), "shared.yaml")
+	if err := ioutil.WriteFile(shared, []byte("tool:\n  token: secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewSharedConfig(shared, "tool")
+	tool.Set("port", 8080)
+	if err := tool.Write(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, f := range []string{file, shared} {
+		info, err := os.Stat(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != 0600 {
+			t.Errorf("%s\ngot:  %v\nwant: %v\n", f, got, os.FileMode(0600))
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/bartdeboer/cobrahooks"
+	"github.com/spf13/cobra"
+)
+
+// UnknownKey is a key of the config file that no bound Struct reads
+type UnknownKey struct {
+	Key string
+	// Suggestion is the nearest known key, empty when no key is close
+	Suggestion string
+}
+
+func (k UnknownKey) String() string {
+	return (&ErrUnknownKey{Key: k.Key, Suggestion: k.Suggestion}).Error()
+}
+
+// UnknownKeys returns the keys of the config file that no bound Struct or collection reads, sorted,
+// with the nearest key of the bound Structs as suggestion. Keys below VersionKey, FeaturesKey and
+// NamespaceKey are known. Returns nil when nothing is bound.
+func UnknownKeys() []UnknownKey {
+	loadConfig()
+	known, prefixes := knownKeys()
+	if len(known) == 0 && len(prefixes) == 0 {
+		return nil
+	}
+	var unknown []UnknownKey
+	for key := range fileSettings(configFile()) {
+		if !isKnownKey(key, known, prefixes) {
+			unknown = append(unknown, UnknownKey{Key: key, Suggestion: suggestKey(key, known)})
+		}
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].Key < unknown[j].Key })
+	return unknown
+}
+
+// knownKeys returns the keys of the bound Structs and the prefixes of the collections and built-in keys
+func knownKeys() (known []string, prefixes []string) {
+	schemaKeys.Lock()
+	defer schemaKeys.Unlock()
+	for key := range schemaKeys.keys {
+		known = append(known, key)
+	}
+	sort.Strings(known)
+	for _, c := range schemaKeys.collections {
+		for _, field := range []string{c.opts.collectionField, c.opts.selectField} {
+			if field != "" && c.opts.parent == nil {
+				prefixes = append(prefixes, strings.ToLower(field))
+			}
+		}
+	}
+	if len(known) == 0 && len(prefixes) == 0 {
+		return nil, nil
+	}
+	for _, key := range []string{VersionKey, FeaturesKey, NamespaceKey} {
+		prefixes = append(prefixes, strings.ToLower(key))
+	}
+	return known, prefixes
+}
+
+// isKnownKey reports whether the key is a known key or below a known key or prefix
+func isKnownKey(key string, known []string, prefixes []string) bool {
+	for _, list := range [][]string{known, prefixes} {
+		for _, k := range list {
+			if key == k || strings.HasPrefix(key, k+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// structKeys returns the lowercase config keys of the Struct fields, with nested Structs flattened
+func structKeys(rt reflect.Type, keyName NamingFunc) []string {
+	var keys []string
+	for _, f := range structFields(rt) {
+		if !f.exported {
+			continue
+		}
+		key := strings.ToLower(keyName(f.name))
+		if f.kind == reflect.Struct && f.typ != secretType {
+			for _, sub := range structKeys(f.typ, func(name string) string { return name }) {
+				keys = append(keys, key+"."+sub)
+			}
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// checkKeys returns an ErrUnknownKey for the first key of the settings that isn't a field of the Struct
+func (o *BindOptions) checkKeys(settings map[string]interface{}, rt reflect.Type) error {
+	known := structKeys(rt, o.keyName)
+	flat := make(map[string]interface{})
+	flattenSettings(settings, "", flat)
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if isKnownKey(key, known, nil) {
+			continue
+		}
+		err := &ErrUnknownKey{Key: key, Suggestion: suggestKey(key, known)}
+		if o.key != "" {
+			err.Key = strings.ToLower(o.key) + "." + err.Key
+			if err.Suggestion != "" {
+				err.Suggestion = strings.ToLower(o.key) + "." + err.Suggestion
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// WarnUnknownKeys warns about the UnknownKeys of the config file when the command runs,
+// catching typos without failing on keys that newer versions may read
+func WarnUnknownKeys(c *cobra.Command) {
+	cobrahooks.OnPersistentPreRun(c, func(cmd *cobra.Command, args []string) error {
+		for _, key := range UnknownKeys() {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s (%s)\n", key, configFile())
+		}
+		return nil
+	})
+}
@@ -0,0 +1,123 @@
+package cfg
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type unknownStruct struct {
+	Mode string
+	Name string
+}
+
+func TestWarnUnknownKeys(t *testing.T) {
+
+	Reset()
+	defer Reset()
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	data := "configVersion: 1\nunkSection:\n  mode: fast\n  mdoe: slow\nunkOther:\n  x: 1\n"
+	if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ReadInConfig()
+	viper.SetConfigFile(file)
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := UnknownKeys(); got != nil {
+		t.Errorf("Expected no unknown keys without bindings, got %v", got)
+	}
+
+	var config unknownStruct
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+	WarnUnknownKeys(rootCmd)
+	BindFlags(rootCmd, &config, Key("unkSection"))
+
+	want := []UnknownKey{
+		{Key: "unkother.x"},
+		{Key: "unksection.mdoe", Suggestion: "unksection.mode"},
+	}
+	if got := UnknownKeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, want)
+	}
+
+	output, err := executeCommand(rootCmd)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(output, `Warning: unknown config key "unksection.mdoe", did you mean "unksection.mode"?`) {
+		t.Errorf("Unexpected output:\n%s", output)
+	}
+
+	if config.Mode != "fast" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config.Mode, "fast")
+	}
+	output, err = executeCommand(NewConfigCommand(), "doctor")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(output, `Warning: unknown config key "unkother.x"`+"\n") {
+		t.Errorf("Unexpected output:\n%s", output)
+	}
+
+	output, err = executeCommand(NewConfigCommand(), "set", "unkSection.nmae", "typo")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(output, `Warning: unknown config key "unkSection.nmae", did you mean "unksection.name"?`) {
+		t.Errorf("Unexpected output:\n%s", output)
+	}
+
+	_, err = executeCommand(NewConfigCommand(), "get", "unkSection.nam")
+	if err == nil || err.Error() != `config key "unkSection.nam" is not set, did you mean "unksection.name"?` {
+		t.Errorf("\ngot:  %v\nwant: %v\n", err, `config key "unkSection.nam" is not set, did you mean "unksection.name"?`)
+	}
+}
+
+func TestStrictKeys(t *testing.T) {
+
+	Reset()
+	defer Reset()
+
+	Set("strictSection.mode", "fast")
+	Set("strictSection.mdoe", "slow")
+
+	var config unknownStruct
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+	BindFlags(rootCmd, &config, Key("strictSection"), StrictKeys)
+
+	_, err := executeCommand(rootCmd)
+	var unknown *ErrUnknownKey
+	if !errors.As(err, &unknown) {
+		t.Fatalf("Expected an ErrUnknownKey, got %v", err)
+	}
+
+	want := ErrUnknownKey{Key: "strictsection.mdoe", Suggestion: "strictsection.mode"}
+	if *unknown != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", *unknown, want)
+	}
+}
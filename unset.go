@@ -0,0 +1,131 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Unset is a config value that removes the key from lower layers.
+// It clears Struct defaults and removes the key from items it is merged with, like with MultiSelect.
+// YAML null can't be used for this, because viper drops keys with null values.
+const Unset = "~unset"
+
+// unsetHook decodes Unset into the zero value of any type
+func unsetHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if s, ok := data.(string); ok && s == Unset {
+		return reflect.Zero(to).Interface(), nil
+	}
+	return data, nil
+}
+
+// clearUnset zeroes the Struct fields that are Unset in the settings.
+// Decoding leaves existing slices and Structs in place, so they are cleared before decoding.
+func (o *BindOptions) clearUnset(rv reflect.Value, settings map[string]interface{}, top bool) {
+	rt := rv.Type()
+	for i := 0; i < rv.NumField(); i++ {
+		fv := rv.Field(i)
+		if !fv.CanSet() {
+			continue
+		}
+		name := rt.Field(i).Name
+		if top {
+			name = o.keyName(name)
+		}
+		for k, v := range settings {
+			if !strings.EqualFold(k, name) {
+				continue
+			}
+			if v == Unset {
+				fv.Set(reflect.Zero(fv.Type()))
+			} else if m, ok := toStringMap(v); ok && fv.Kind() == reflect.Struct {
+				o.clearUnset(fv, m, false)
+			}
+		}
+	}
+}
+
+// UnsetKey removes the key from the settings of the config file and the values that were Set,
+// so Write removes it from the file. The environment, flags and defaults may still set the key.
+// Like SetPath, list items can be addressed like "servers[1]" or "servers[name=dev].port".
+func UnsetKey(path string) error {
+	steps, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	loadConfig()
+	mu.Lock()
+	defer resetCache()
+	defer mu.Unlock()
+	file, inFile := deleteStep(deepCopy(layers.file), steps)
+	set, wasSet := deleteStep(deepCopy(layers.set), steps)
+	if !inFile && !wasSet {
+		return notSetError(path)
+	}
+	if inFile {
+		layers.file = file.(map[string]interface{})
+	}
+	if wasSet {
+		layers.set = set.(map[string]interface{})
+		pruneEmpty(layers.set)
+		syncOverride(strings.ToLower(steps[0].key))
+	}
+	return rebuildConfig()
+}
+
+// deleteStep returns the container without the value at the steps and whether it was found
+func deleteStep(container interface{}, steps []queryStep) (interface{}, bool) {
+	s := steps[0]
+	if s.key != "" {
+		m, ok := toStringMap(container)
+		if !ok {
+			return container, false
+		}
+		for k, v := range m {
+			if !strings.EqualFold(k, s.key) {
+				continue
+			}
+			if len(steps) == 1 {
+				delete(m, k)
+				return m, true
+			}
+			child, ok := deleteStep(v, steps[1:])
+			if ok {
+				m[k] = child
+			}
+			return m, ok
+		}
+		return container, false
+	}
+	list, ok := toList(container)
+	if !ok {
+		return container, false
+	}
+	i := s.index
+	if s.field != "" {
+		i = -1
+		for j, item := range list {
+			if m, ok := toStringMap(item); ok {
+				if v := lookupFold(m, s.field); v != nil && fmt.Sprintf("%v", v) == s.value {
+					i = j
+					break
+				}
+			}
+		}
+	}
+	if i < 0 || i >= len(list) {
+		return container, false
+	}
+	if len(steps) == 1 {
+		return append(list[:i], list[i+1:]...), true
+	}
+	child, ok := deleteStep(list[i], steps[1:])
+	if ok {
+		list[i] = child
+	}
+	return list, ok
+}
@@ -0,0 +1,136 @@
+package cfg
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+type unsetStruct struct {
+	Name   string
+	Port   int
+	Tags   []string
+	Labels map[string]string
+}
+
+func TestResolveUnset(t *testing.T) {
+
+	Set("unsetSection", map[string]interface{}{
+		"port":   Unset,
+		"tags":   Unset,
+		"labels": Unset,
+	})
+
+	config := unsetStruct{
+		Name:   "default",
+		Port:   8080,
+		Tags:   []string{"a"},
+		Labels: map[string]string{"app": "cfg"},
+	}
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+
+	if err := Resolve(flags, &config, Key("unsetSection")); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if want := (unsetStruct{Name: "default"}); !reflect.DeepEqual(config, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+}
+
+func TestMergeMapsUnset(t *testing.T) {
+
+	base := map[string]interface{}{
+		"region": "eu-west-1",
+		"tags":   []interface{}{"a", "b"},
+		"nested": map[string]interface{}{"keep": 1, "drop": 2},
+	}
+	overlay := map[string]interface{}{
+		"tags":   Unset,
+		"nested": map[string]interface{}{"drop": Unset},
+	}
+
+	want := map[string]interface{}{
+		"region": "eu-west-1",
+		"nested": map[string]interface{}{"keep": 1},
+	}
+
+	if got := mergeMaps(base, overlay); !reflect.DeepEqual(got, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, want)
+	}
+}
+
+func TestUnsetKey(t *testing.T) {
+
+	Reset()
+	t.Cleanup(Reset)
+
+	Set("unsetKeySection", map[string]interface{}{
+		"keep":  1,
+		"drop":  2,
+		"items": []interface{}{map[string]interface{}{"name": "a"}, map[string]interface{}{"name": "b"}},
+	})
+
+	for _, path := range []string{"unsetKeySection.drop", "unsetKeySection.items[name=a]"} {
+		if err := UnsetKey(path); err != nil {
+			t.Fatalf("Unexpected error for %s: %v", path, err)
+		}
+	}
+
+	if got := Get("unsetKeySection.drop"); got != nil {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, nil)
+	}
+
+	if got := GetInt("unsetKeySection.keep"); got != 1 {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, 1)
+	}
+
+	want := []interface{}{map[string]interface{}{"name": "b"}}
+	if got := Get("unsetKeySection.items"); !reflect.DeepEqual(got, want) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, want)
+	}
+
+	if err := UnsetKey("unsetKeySection.missing"); err == nil {
+		t.Errorf("Expected an error for a missing key")
+	}
+}
+
+func TestUnsetKeyKeepsEnv(t *testing.T) {
+
+	file := useConfigFile(t, "unsetEnv: File\nunsetFile: File\n")
+	t.Setenv("UNSETENV", "Env")
+	viper.AutomaticEnv()
+	SetDefault("unsetDefault", "Default")
+
+	for _, key := range []string{"unsetEnv", "unsetFile"} {
+		if err := UnsetKey(key); err != nil {
+			t.Fatalf("Unexpected error for %s: %v", key, err)
+		}
+	}
+
+	if got := GetString("unsetEnv"); got != "Env" {
+		t.Errorf("\ngot:  %v\nwant: %v\n", got, "Env")
+	}
+
+	if err := UnsetKey("unsetDefault"); err == nil {
+		t.Errorf("Expected an error for a key that is only a default")
+	}
+
+	if err := Write(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"unsetenv", "unsetfile"} {
+		if strings.Contains(string(b), key) {
+			t.Errorf("Unexpected %s in %q", key, b)
+		}
+	}
+}
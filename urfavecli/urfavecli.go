@@ -0,0 +1,62 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package urfavecli binds cfg Structs to urfave/cli commands.
+package urfavecli
+
+import (
+	"strconv"
+
+	"github.com/bartdeboer/cfg"
+	"github.com/spf13/pflag"
+	"github.com/urfave/cli/v2"
+)
+
+// Bind generates urfave/cli flags for the Struct and a Before function that
+// unmarshals the config into the Struct overriding with any flags that are set.
+func Bind(rawVal interface{}, options ...func(*cfg.BindOptions)) ([]cli.Flag, cli.BeforeFunc) {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	cfg.CreateFlags(flags, rawVal, options...)
+	var cliFlags []cli.Flag
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Value.Type() == "bool" {
+			cliFlags = append(cliFlags, &cli.BoolFlag{Name: f.Name, Usage: f.Usage})
+			return
+		}
+		cliFlags = append(cliFlags, &cli.GenericFlag{Name: f.Name, Usage: f.Usage, Value: f.Value})
+	})
+	before := func(c *cli.Context) error {
+		var err error
+		flags.VisitAll(func(f *pflag.Flag) {
+			if !c.IsSet(f.Name) || err != nil {
+				return
+			}
+			if f.Value.Type() == "bool" {
+				err = f.Value.Set(strconv.FormatBool(c.Bool(f.Name)))
+			}
+			f.Changed = true
+		})
+		if err != nil {
+			return err
+		}
+		return cfg.Resolve(flags, rawVal, options...)
+	}
+	return cliFlags, before
+}
+
+// BindCommand adds the generated flags to the command and runs the merge before any existing Before function
+func BindCommand(cmd *cli.Command, rawVal interface{}, options ...func(*cfg.BindOptions)) {
+	flags, before := Bind(rawVal, options...)
+	cmd.Flags = append(cmd.Flags, flags...)
+	next := cmd.Before
+	cmd.Before = func(c *cli.Context) error {
+		if err := before(c); err != nil {
+			return err
+		}
+		if next != nil {
+			return next(c)
+		}
+		return nil
+	}
+}
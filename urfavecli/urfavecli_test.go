@@ -0,0 +1,73 @@
+package urfavecli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bartdeboer/cfg"
+	"github.com/spf13/viper"
+	"github.com/urfave/cli/v2"
+)
+
+var yamlExample = []byte(`
+server:
+  Host: example.com
+  Port: 80
+  Tls: true
+`)
+
+func init() {
+	cfg.ConfigLoader = func() {
+		viper.SetConfigType("yaml")
+		viper.ReadConfig(bytes.NewBuffer(yamlExample))
+	}
+}
+
+type serverStruct struct {
+	Host    string
+	Port    int
+	Tls     bool
+	Verbose bool
+}
+
+func TestBindCommand(t *testing.T) {
+
+	var (
+		config serverStruct
+		ran    bool
+	)
+
+	cmd := &cli.Command{
+		Name: "serve",
+		Action: func(_ *cli.Context) error {
+			ran = true
+			return nil
+		},
+	}
+
+	BindCommand(cmd, &config, cfg.Key("server"))
+
+	app := &cli.App{
+		Name:     "app",
+		Commands: []*cli.Command{cmd},
+	}
+
+	if err := app.Run([]string{"app", "serve", "--port", "8080", "--verbose"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if !ran {
+		t.Errorf("Command did not run")
+	}
+
+	want := serverStruct{
+		Host:    "example.com",
+		Port:    8080,
+		Tls:     true,
+		Verbose: true,
+	}
+
+	if config != want {
+		t.Errorf("\ngot:  %v\nwant: %v\n", config, want)
+	}
+}
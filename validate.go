@@ -0,0 +1,121 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/bartdeboer/cobrahooks"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ErrInvalidConfig is returned by the --validate-config flag when the config has problems
+var ErrInvalidConfig = errors.New("invalid config")
+
+// collectionBinding is a collection bound to items of a Struct type, to validate the config against
+type collectionBinding struct {
+	opts *BindCollectionOptions
+	rt   reflect.Type
+	// selects reports whether an item of the collection is selected
+	selects bool
+}
+
+// registerCollection adds the collection to the bindings validated by ValidateConfig
+func registerCollection(opts *BindCollectionOptions, rt reflect.Type, selects bool) {
+	schemaKeys.Lock()
+	defer schemaKeys.Unlock()
+	schemaKeys.collections = append(schemaKeys.collections, collectionBinding{opts: opts, rt: rt, selects: selects})
+}
+
+// ValidateConfig loads the config and checks it against every bound Struct and collection:
+// the settings must decode into the Structs, fields with choices and addr tags must be valid
+// and the selected collection items must exist. It returns all problems that were found.
+func ValidateConfig() []error {
+	loadConfig()
+	mu.RLock()
+	settings := deepCopy(viper.AllSettings()).(map[string]interface{})
+	mu.RUnlock()
+	schemaKeys.Lock()
+	bindings, collections := schemaKeys.bindings, schemaKeys.collections
+	schemaKeys.Unlock()
+	var errs []error
+	for _, b := range bindings {
+		if err := validateBinding(settings, b); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, c := range collections {
+		errs = append(errs, c.validate()...)
+	}
+	return errs
+}
+
+// validate decodes every item of the collection and checks that the selected items exist
+func (c collectionBinding) validate() []error {
+	o := c.opts
+	coll, err := o.items()
+	if err != nil {
+		return []error{&ErrDecode{Key: o.collectionField, Type: "[]" + c.rt.String(), Err: err}}
+	}
+	var errs []error
+	for i, item := range coll {
+		key := fmt.Sprintf("%s[%d]", o.collectionField, i)
+		rv := reflect.New(c.rt)
+		if err := decode(item, rv.Interface()); err != nil {
+			errs = append(errs, &ErrDecode{Key: key, Type: c.rt.String(), Value: item, Err: err})
+			continue
+		}
+		b := schemaBinding{key: key, rt: c.rt, keyName: func(name string) string { return name }}
+		if err := validateChoices(rv.Elem(), b); err != nil {
+			errs = append(errs, err)
+		}
+		if err := validateBindingAddrs(rv.Elem(), b); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if !c.selects {
+		return errs
+	}
+	selectValue := o.selectedValue()
+	if selectValue == "" {
+		selectValue = o.defaultItemId(coll)
+	}
+	if selectValue == "" {
+		return errs
+	}
+	for _, value := range o.selectedValues(selectValue) {
+		if o.findItem(coll, value) < 0 {
+			errs = append(errs, o.itemNotFound(value, o.collectionIds()))
+		}
+	}
+	return errs
+}
+
+// AddValidateFlag adds the persistent --validate-config flag that loads and validates the config
+// with ValidateConfig, prints the problems and exits without running the command.
+// It fails with ErrInvalidConfig when problems were found, so CI pipelines can gate on it.
+// Add the flag to the root command before binding Structs, so it runs before they are resolved.
+func AddValidateFlag(c *cobra.Command) {
+	c.PersistentFlags().Bool("validate-config", false, "Validate the config and exit without running the command")
+	cobrahooks.OnPersistentPreRun(c, func(cmd *cobra.Command, args []string) error {
+		if validate, _ := cmd.Flags().GetBool("validate-config"); !validate {
+			return nil
+		}
+		errs := ValidateConfig()
+		for _, err := range errs {
+			fmt.Fprintln(cmd.OutOrStdout(), "Error:", err)
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("%w: %d problems found", ErrInvalidConfig, len(errs))
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Config is valid (%s)\n", configFile())
+		cmd.Run = nil
+		cmd.RunE = func(*cobra.Command, []string) error { return nil }
+		return nil
+	})
+}
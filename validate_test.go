@@ -0,0 +1,99 @@
+package cfg
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type validateStruct struct {
+	Mode string `choices:"fast,safe"`
+}
+
+type validateItem struct {
+	Name string
+	Port int
+}
+
+func TestValidateFlag(t *testing.T) {
+
+	Reset()
+	defer Reset()
+
+	// only validate the bindings of this test
+	schemaKeys.Lock()
+	bindings, collections := schemaKeys.bindings, schemaKeys.collections
+	schemaKeys.bindings, schemaKeys.collections = nil, nil
+	schemaKeys.Unlock()
+	defer func() {
+		schemaKeys.Lock()
+		schemaKeys.bindings, schemaKeys.collections = bindings, collections
+		schemaKeys.Unlock()
+	}()
+
+	dir, err := ioutil.TempDir("", "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	data := "valSection:\n  mode: slow\nvalSelected: third\nvalItems:\n  - name: first\n    port: 80\n  - name: second\n    port: many\n"
+	if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ReadInConfig()
+	viper.SetConfigFile(file)
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	ran := false
+	var config validateStruct
+	var item validateItem
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(_ *cobra.Command, _ []string) { ran = true },
+	}
+	AddValidateFlag(rootCmd)
+	BindFlags(rootCmd, &config, Key("valSection"))
+	BindCollectionItem(rootCmd, &item, CollectionField("valItems"), SelectField("valSelected"), IdField("name"))
+
+	output, err := executeCommand(rootCmd, "--validate-config")
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("\ngot:  %v\nwant: %v\n", err, ErrInvalidConfig)
+	}
+	for _, want := range []string{
+		`invalid value for valsection.mode: "slow" is not one of fast, safe`,
+		"valItems[1]",
+		`valItems: item "third" not found (available: first, second)`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Missing %q in output:\n%s", want, output)
+		}
+	}
+	if ran {
+		t.Errorf("Expected the command not to run")
+	}
+
+	data = "valSection:\n  mode: safe\nvalSelected: first\nvalItems:\n  - name: first\n    port: 80\n"
+	if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err = executeCommand(rootCmd, "--validate-config")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "Config is valid") || ran {
+		t.Errorf("Unexpected output:\n%s", output)
+	}
+}
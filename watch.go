@@ -0,0 +1,65 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watch reloads the config file when it changes, notifying the reload listeners.
+// It watches the directory of the file instead of the file, and compares the file the path resolves to
+// and its checksum on every event in the directory. This also catches updates of Kubernetes ConfigMap
+// and Secret volumes, which replace the ..data symlink the file points to instead of writing the file.
+// Call the returned function to stop watching.
+func Watch() (stop func(), err error) {
+	file := configFile()
+	if file == "" {
+		return nil, ErrConfigNotFound
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Add(filepath.Dir(file)); err != nil {
+		w.Close()
+		return nil, err
+	}
+	real, _ := filepath.EvalSymlinks(file)
+	sum := Checksum()
+	go func() {
+		for {
+			select {
+			case _, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				current, _ := filepath.EvalSymlinks(file)
+				currentSum := fileChecksum(file)
+				if current == real && currentSum == sum {
+					continue
+				}
+				real, sum = current, currentSum
+				if currentSum == "" {
+					// removed, or in the middle of being replaced
+					continue
+				}
+				if err := Reload(); err != nil {
+					fmt.Fprintf(WarningOutput, "Warning: reloading config: %s\n", err)
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				fmt.Fprintf(WarningOutput, "Warning: watching config: %s\n", err)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { w.Close() }) }, nil
+}